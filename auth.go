@@ -0,0 +1,330 @@
+package requests
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// redacted replaces credentials when a request is logged or dumped.
+const redacted = "[REDACTED]"
+
+// authenticator authorizes requests that need more than a static header.
+type authenticator interface {
+	// authorize sets the credentials of req before it is sent.
+	authorize(req *Request) error
+	// reauthorize learns from a 401 response and reports whether req should be sent again.
+	reauthorize(req *Request, resp *Response) bool
+}
+
+// BasicAuth authenticates with HTTP Basic authentication.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (a BasicAuth) Do(req *Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// Bearer authenticates with a bearer token in the Authorization header.
+type Bearer string
+
+func (b Bearer) Do(req *Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(b))
+	return nil
+}
+
+// APIKey sends an API key in the header Name, or in the query parameter Name if InQuery is set.
+type APIKey struct {
+	Name    string
+	Value   string
+	InQuery bool
+}
+
+func (k APIKey) Do(req *Request) error {
+	if k.Name == "" {
+		return errors.New("go-requests: APIKey name is empty")
+	}
+	if k.InQuery {
+		req.secretParams = append(req.secretParams, k.Name)
+		return Params{k.Name: k.Value}.Do(req)
+	}
+	req.secretHeaders = append(req.secretHeaders, k.Name)
+	req.Header.Set(k.Name, k.Value)
+	return nil
+}
+
+// DigestAuth authenticates with HTTP Digest authentication (RFC 7616). The challenge
+// round trip is performed automatically and its nonce is reused by the following
+// requests, so share one DigestAuth created by NewDigestAuth across requests.
+type DigestAuth struct {
+	Username string
+	Password string
+
+	mu        sync.Mutex
+	challenge *digestChallenge
+	nc        int
+}
+
+func NewDigestAuth(username, password string) *DigestAuth {
+	return &DigestAuth{Username: username, Password: password}
+}
+
+func (d *DigestAuth) Do(req *Request) error {
+	req.auth = d
+	return nil
+}
+
+func (d *DigestAuth) authorize(req *Request) error {
+	d.mu.Lock()
+	c := d.challenge
+	d.nc++
+	nc := d.nc
+	d.mu.Unlock()
+	if c == nil {
+		return nil
+	}
+	authorization, err := c.authorization(req, d.Username, d.Password, nc)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", authorization)
+	return nil
+}
+
+func (d *DigestAuth) reauthorize(req *Request, resp *Response) bool {
+	var c *digestChallenge
+	for _, value := range resp.Header.Values("WWW-Authenticate") {
+		if c = parseDigestChallenge(value); c != nil {
+			break
+		}
+	}
+	if c == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// the cached nonce was rejected without being stale: the credentials are wrong.
+	if d.challenge != nil && d.challenge.nonce == c.nonce && !c.stale {
+		return false
+	}
+	d.challenge, d.nc = c, 0
+	return true
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	stale     bool
+}
+
+// parseDigestChallenge parses a WWW-Authenticate header value, it returns nil if it is not a Digest challenge.
+func parseDigestChallenge(value string) *digestChallenge {
+	value = strings.TrimSpace(value)
+	if len(value) < 7 || !strings.EqualFold(value[:7], "Digest ") {
+		return nil
+	}
+	params := parseAuthParams(value[7:])
+	if params["nonce"] == "" {
+		return nil
+	}
+	c := &digestChallenge{
+		realm:     params["realm"],
+		nonce:     params["nonce"],
+		opaque:    params["opaque"],
+		algorithm: params["algorithm"],
+		stale:     strings.EqualFold(params["stale"], "true"),
+	}
+	for _, qop := range strings.Split(params["qop"], ",") {
+		qop = strings.TrimSpace(qop)
+		if qop == "auth" || (qop == "auth-int" && c.qop == "") {
+			c.qop = qop
+		}
+	}
+	return c
+}
+
+// parseAuthParams parses comma separated key=value pairs whose values may be quoted.
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for s != "" {
+		s = strings.TrimLeft(s, " \t,")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i < len(s) {
+				i++
+			}
+			value, s = b.String(), s[i:]
+		} else if end := strings.IndexByte(s, ','); end >= 0 {
+			value, s = strings.TrimSpace(s[:end]), s[end:]
+		} else {
+			value, s = strings.TrimSpace(s), ""
+		}
+		params[key] = value
+	}
+	return params
+}
+
+func (c *digestChallenge) authorization(req *Request, username, password string, nc int) (string, error) {
+	var h func() hash.Hash
+	algorithm := strings.ToUpper(c.algorithm)
+	switch algorithm {
+	case "", "MD5", "MD5-SESS":
+		h = md5.New
+	case "SHA-256", "SHA-256-SESS":
+		h = sha256.New
+	default:
+		return "", errors.New(fmt.Sprintf("go-requests: unsupported digest algorithm %s", c.algorithm))
+	}
+	digest := func(parts ...string) string {
+		d := h()
+		_, _ = io.WriteString(d, strings.Join(parts, ":"))
+		return hex.EncodeToString(d.Sum(nil))
+	}
+
+	cnonce := newCnonce()
+	ncValue := fmt.Sprintf("%08x", nc)
+	uri := req.URL.RequestURI()
+	ha1 := digest(username, c.realm, password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = digest(ha1, c.nonce, cnonce)
+	}
+	ha2 := digest(req.Method, uri)
+	if c.qop == "auth-int" {
+		body, err := req.bodyBytes()
+		if err != nil {
+			return "", err
+		}
+		d := h()
+		d.Write(body)
+		ha2 = digest(req.Method, uri, hex.EncodeToString(d.Sum(nil)))
+	}
+
+	var response string
+	if c.qop == "" {
+		response = digest(ha1, c.nonce, ha2)
+	} else {
+		response = digest(ha1, c.nonce, ncValue, cnonce, c.qop, ha2)
+	}
+
+	fields := []string{
+		fmt.Sprintf(`username="%s"`, username),
+		fmt.Sprintf(`realm="%s"`, c.realm),
+		fmt.Sprintf(`nonce="%s"`, c.nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if c.algorithm != "" {
+		fields = append(fields, "algorithm="+c.algorithm)
+	}
+	if c.opaque != "" {
+		fields = append(fields, fmt.Sprintf(`opaque="%s"`, c.opaque))
+	}
+	if c.qop != "" {
+		fields = append(fields, "qop="+c.qop, "nc="+ncValue, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	return "Digest " + strings.Join(fields, ", "), nil
+}
+
+func newCnonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// bodyBytes reads a copy of the request body, it is empty when the body is not replayable.
+func (req *Request) bodyBytes() ([]byte, error) {
+	if req.GetBody == nil {
+		return nil, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// redactedHeader returns a copy of the request header with credentials masked.
+func (req *Request) redactedHeader() http.Header {
+	header := redactHeader(req.Header.Clone(), []string{"Authorization", "Proxy-Authorization"})
+	for _, key := range req.secretHeaders {
+		if header.Get(key) != "" {
+			header.Set(key, redacted)
+		}
+	}
+	return header
+}
+
+// redactHeader masks the values of keys in header, keeping the scheme of authorization headers.
+func redactHeader(header http.Header, keys []string) http.Header {
+	for _, key := range keys {
+		values := header.Values(key)
+		if len(values) == 0 {
+			continue
+		}
+		header.Del(key)
+		for _, value := range values {
+			// keep the scheme, e.g. "Basic [REDACTED]"
+			if i := strings.IndexByte(value, ' '); i > 0 && strings.HasSuffix(http.CanonicalHeaderKey(key), "Authorization") {
+				header.Add(key, value[:i]+" "+redacted)
+			} else {
+				header.Add(key, redacted)
+			}
+		}
+	}
+	return header
+}
+
+// redactedURL returns the request url with the credentials in its query masked.
+func (req *Request) redactedURL() string {
+	u := *req.URL
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), redacted)
+	}
+	if len(req.secretParams) > 0 {
+		query := u.Query()
+		for _, name := range req.secretParams {
+			if _, ok := query[name]; ok {
+				query.Set(name, redacted)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// String describes the request with its credentials redacted.
+func (req *Request) String() string {
+	return req.Method + " " + req.redactedURL()
+}
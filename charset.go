@@ -0,0 +1,68 @@
+package requests
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// contentTypeCharset returns the charset parameter of a Content-Type header value.
+func contentTypeCharset(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(params["charset"])
+}
+
+// charsetReader returns a reader decoding input from the charset label to UTF-8.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	encoding, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, errors.Wrap(ErrUnrecognizedEncoding, label)
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+// sniffSize is how much of a body is searched for a charset declaration.
+const sniffSize = 1024
+
+var (
+	xmlDeclaration = regexp.MustCompile(`^\s*<\?xml[^>]*\sencoding\s*=\s*["']([\w.:-]+)["']`)
+	htmlMeta       = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?([\w.:-]+)`)
+)
+
+// sniffCharset returns the charset declared at the start of an XML or HTML document.
+func sniffCharset(data []byte) string {
+	if len(data) > sniffSize {
+		data = data[:sniffSize]
+	}
+	if m := xmlDeclaration.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	if m := htmlMeta.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// decodeCharset decodes data from the charset label to UTF-8.
+func decodeCharset(label string, data []byte) ([]byte, error) {
+	encoding, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, errors.Wrap(ErrUnrecognizedEncoding, label)
+	}
+	name, _ := htmlindex.Name(encoding)
+	if name == "utf-8" {
+		return bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), nil
+	}
+	return encoding.NewDecoder().Bytes(data)
+}
@@ -1,9 +1,13 @@
 package requests
 
 import (
-	"encoding/json"
+	"context"
 	"net/http"
+	"net/http/cookiejar"
+	neturl "net/url"
+	"reflect"
 	"strings"
+	"sync"
 )
 
 const (
@@ -52,7 +56,19 @@ func Head(url string, opts ...ReqOption) (*Response, error) {
 
 type Client struct {
 	*http.Client
-	hooks []Hook
+	// mu guards middlewares, which is replaced rather than modified so in-flight requests keep their copy.
+	mu          sync.RWMutex
+	middlewares []middleware
+	retry       *Retry
+	baseURL     string
+	options     []ReqOption
+	codec       Codec
+	// decoders are the response decoders set by WithDecoder, the built-in ones if nil.
+	decoders map[string]Decoder
+
+	raiseForStatus bool
+	phases         phaseTimeouts
+	trace          bool
 }
 
 var DefaultClient = &Client{Client: http.DefaultClient}
@@ -65,6 +81,12 @@ func NewClient(opts ...ClientOption) *Client {
 	return c
 }
 
+// NewSession returns a Client that keeps cookies across requests, like a requests.Session in Python.
+func NewSession(opts ...ClientOption) *Client {
+	jar, _ := cookiejar.New(nil)
+	return NewClient(append([]ClientOption{WithJar(jar)}, opts...)...)
+}
+
 func (s *Client) Request(method, url string, opts ...ReqOption) (*Response, error) {
 	method = strings.ToUpper(method)
 	switch method {
@@ -73,46 +95,190 @@ func (s *Client) Request(method, url string, opts ...ReqOption) (*Response, erro
 		return nil, ErrInvalidMethod
 	}
 
-	req, err := NewRequest(method, url)
+	req, err := NewRequest(method, s.resolveURL(url))
 	if err != nil {
 		return nil, err
 	}
 
+	req.defaults = true
+	for _, opt := range s.options {
+		if err = opt.Do(req); err != nil {
+			return nil, err
+		}
+	}
+	req.defaults = false
 	for _, opt := range opts {
 		err = opt.Do(req)
 		if err != nil {
 			return nil, err
 		}
 	}
+	if req.codec == nil {
+		req.codec = s.codec
+	}
+	if req.Header.Get("Accept-Encoding") == "" {
+		if accept := acceptEncoding(s.decoderMap()); accept != "" {
+			req.Header.Set("Accept-Encoding", accept)
+		}
+	}
 	if err = req.loadBody(); err != nil {
 		return nil, err
 	}
 
-	for _, h := range s.hooks {
-		h.BeforeProcess(req)
+	cancel := func() {}
+	if req.timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), req.timeout)
+		req.Request = req.WithContext(ctx)
 	}
-	var result *http.Response
-	var resp *Response
-	success := make(chan struct{})
-	done := req.Context().Done()
-	if done != nil {
-		go func() {
-			result, err = s.Do(req.Request)
-			close(success)
-		}()
-		select {
-		case <-done:
-			err = ErrTimeout
-		case <-success:
+	if raw := req.rawBody; raw != nil {
+		release := cancel
+		cancel = func() {
+			release()
+			raw.close()
 		}
+	}
+	resp, err := s.retryLoop(req)
+	if resp != nil && req.stream {
+		// the body is still being read, the timer is released once it is closed.
+		resp.closeWith(cancel)
 	} else {
-		result, err = s.Do(req.Request)
+		cancel()
+	}
+	raise := s.raiseForStatus
+	if req.raiseForStatus != nil {
+		raise = *req.raiseForStatus
+	}
+	if err == nil && raise {
+		err = resp.RaiseForStatus()
+	}
+	return resp, err
+}
+
+// retryLoop sends req until it succeeds or its Retry gives up.
+func (s *Client) retryLoop(req *Request) (resp *Response, err error) {
+	retry := s.retry
+	if req.retry != nil {
+		retry = req.retry
+	}
+	for {
+		req.attempt++
+		if req.attempt > 1 {
+			if err = req.rewind(); err != nil {
+				return resp, err
+			}
+		}
+		resp, err = s.do(req)
+		if !retry.retryable(req, resp, err) {
+			return resp, err
+		}
+		delay, ok := retry.delay(req.attempt, resp)
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			_ = resp.Close()
+		}
+		if err = sleep(req.Context(), delay); err != nil {
+			return nil, timeoutErr(req.Context(), err)
+		}
+	}
+}
+
+// do performs a single attempt of req, answering one authentication challenge if needed.
+func (s *Client) do(req *Request) (*Response, error) {
+	if req.auth == nil {
+		return s.send(req)
+	}
+	if err := req.auth.authorize(req); err != nil {
+		req.closeUnsent()
+		return nil, err
+	}
+	resp, err := s.send(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !req.replayable() || !req.auth.reauthorize(req, resp) {
+		return resp, err
+	}
+	if err = req.rewind(); err != nil {
+		return resp, err
 	}
-	if err == nil {
-		resp, err = NewResponse(result)
+	if err = req.auth.authorize(req); err != nil {
+		req.closeUnsent()
+		return resp, err
 	}
-	for _, h := range s.hooks {
-		h.AfterProcess(req, resp, err)
+	_ = resp.Close()
+	return s.send(req)
+}
+
+// send runs a single round trip of req through the client middlewares, then the request hooks.
+func (s *Client) send(req *Request) (*Response, error) {
+	handler := Handler(s.roundTrip)
+	for i := len(req.hooks) - 1; i >= 0; i-- {
+		handler = HookMiddleware(req.hooks[i])(handler)
+	}
+	s.mu.RLock()
+	middlewares := s.middlewares
+	s.mu.RUnlock()
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i].fn(handler)
+	}
+	resp, err := handler(req)
+	req.closeUnsent()
+	if resp != nil {
+		if resp.codec == nil {
+			resp.codec = req.codec
+		}
+		if resp.request == nil {
+			resp.request = req
+		}
+	}
+	return resp, err
+}
+
+// roundTrip sends req over the network and wraps the response.
+func (s *Client) roundTrip(req *Request) (*Response, error) {
+	httpReq := req.Request
+	watch := newPhaseWatch(req.Context(), s.phases.merge(req.phases))
+	if watch != nil {
+		httpReq = watch.request(httpReq)
+	}
+	var trace *timingTrace
+	if req.trace || s.trace {
+		trace = newTimingTrace()
+		httpReq = trace.request(httpReq)
+	}
+	var resp *Response
+	req.consumed = true
+	result, err := s.Do(httpReq)
+	if watch != nil {
+		err = watch.err(err)
+		if err == nil {
+			result.Body = watch.body(result.Body)
+		}
+	}
+	var timings *Timings
+	if trace != nil && err == nil {
+		timings = &Timings{}
+		trace.headers(timings)
+		if req.stream {
+			result.Body = &timedBody{ReadCloser: result.Body, trace: trace, timings: timings}
+		}
+	}
+	if err == nil && req.stream {
+		resp, err = newStreamResponse(result, s.decoders)
+	} else if err == nil {
+		resp, err = newResponse(result, s.decoders)
+		if timings != nil {
+			trace.done(timings)
+		}
+	}
+	err = timeoutErr(req.Context(), err)
+	if watch != nil && resp != nil && req.stream && err == nil {
+		resp.closeWith(watch.cancel)
+	} else if watch != nil {
+		watch.cancel()
+	}
+	if resp != nil {
+		resp.Timings = timings
 	}
 	return resp, err
 }
@@ -145,19 +311,87 @@ func (s *Client) Head(url string, opts ...ReqOption) (*Response, error) {
 	return s.Request(HEAD, url, opts...)
 }
 
+// resolveURL joins a relative url to the base url set by WithBaseURL.
+func (s *Client) resolveURL(url string) string {
+	if s.baseURL == "" {
+		return url
+	}
+	if u, err := neturl.Parse(url); err == nil && u.IsAbs() {
+		return url
+	}
+	if url == "" || strings.HasPrefix(url, "?") {
+		return s.baseURL + url
+	}
+	return strings.TrimRight(s.baseURL, "/") + "/" + strings.TrimLeft(url, "/")
+}
+
+// middleware is a registered Middleware, hook is set when it was added by AddHook.
+type middleware struct {
+	fn   Middleware
+	hook Hook
+}
+
+// AddHook runs h around every attempt of a request, it is added after the middlewares already in use.
 func (s *Client) AddHook(h Hook) {
-	s.hooks = append(s.hooks, h)
+	s.add(middleware{fn: HookMiddleware(h), hook: h})
+}
+
+// RemoveHook removes every hook equal to h added by AddHook, and reports whether one was found.
+// Requests already in flight keep running it.
+func (s *Client) RemoveHook(h Hook) bool {
+	return s.ReplaceHook(h, nil)
 }
 
-var unmarshal = json.Unmarshal
-var marshal = json.Marshal
+// ReplaceHook replaces every hook equal to old added by AddHook with new, keeping its position,
+// and reports whether one was found. A nil new removes them.
+func (s *Client) ReplaceHook(old, new Hook) bool {
+	if old == nil || !reflect.TypeOf(old).Comparable() {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := false
+	middlewares := make([]middleware, 0, len(s.middlewares))
+	for _, m := range s.middlewares {
+		if m.hook == old {
+			found = true
+			if new == nil {
+				continue
+			}
+			m = middleware{fn: HookMiddleware(new), hook: new}
+		}
+		middlewares = append(middlewares, m)
+	}
+	s.middlewares = middlewares
+	return found
+}
 
-////SetUnmarshal Set custom Unmarshal functions, default is json.Unmarshal
-//func SetUnmarshal(f func(data []byte, v interface{}) error) {
-//	unmarshal = f
-//}
-//
-////SetMarshal Set custom Marshal functions, default is json.Marshal
-//func SetMarshal(f func(v interface{}) ([]byte, error)) {
-//	marshal = f
-//}
+// Hooks returns the hooks added by AddHook, in order.
+func (s *Client) Hooks() []Hook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var hooks []Hook
+	for _, m := range s.middlewares {
+		if m.hook != nil {
+			hooks = append(hooks, m.hook)
+		}
+	}
+	return hooks
+}
+
+// Use wraps every attempt of a request with middlewares, the first one registered,
+// by Use, WithMiddleware or AddHook, is the outermost.
+func (s *Client) Use(middlewares ...Middleware) {
+	added := make([]middleware, 0, len(middlewares))
+	for _, fn := range middlewares {
+		added = append(added, middleware{fn: fn})
+	}
+	s.add(added...)
+}
+
+func (s *Client) add(added ...middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	middlewares := make([]middleware, 0, len(s.middlewares)+len(added))
+	s.middlewares = append(append(middlewares, s.middlewares...), added...)
+}
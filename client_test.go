@@ -106,45 +106,3 @@ func BenchmarkGetRequest(b *testing.B) {
 		//resp.Text()
 	}
 }
-
-//
-//func TestSetUnmarshalAndSetMarshal(t *testing.T) {
-//	type args struct {
-//		v         interface{}
-//		unmarshal func(data []byte, v interface{}) error
-//		marshal   func(v interface{}) ([]byte, error)
-//	}
-//	tests := []struct {
-//		name string
-//		args args
-//	}{
-//		{args: args{v: map[string]interface{}{"a": float64(1), "b": "x"},
-//			unmarshal: func(data []byte, v interface{}) error {
-//				data = data[:len(data)-1]
-//				return json.Unmarshal(data, v)
-//			},
-//			marshal: func(v interface{}) ([]byte, error) {
-//				bytes, err := json.Marshal(v)
-//				bytes = append(bytes, 's')
-//				return bytes, err
-//			}}},
-//	}
-//	for _, tt := range tests {
-//		t.Run(tt.name, func(t *testing.T) {
-//			SetMarshal(tt.args.marshal)
-//			SetUnmarshal(tt.args.unmarshal)
-//			gotBytes, err := marshal(tt.args.v)
-//			if err != nil {
-//				panic(err)
-//			}
-//			t.Log(gotBytes, string(gotBytes))
-//			got := make(map[string]interface{})
-//			if err := unmarshal(gotBytes, &got); err != nil {
-//				panic(err)
-//			}
-//			if !reflect.DeepEqual(got, tt.args.v) {
-//				t.Errorf("SetUnmarshalAndSetMarshal() got = %v, want %v", got, tt.args.v)
-//			}
-//		})
-//	}
-//}
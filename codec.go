@@ -0,0 +1,56 @@
+package requests
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Codec marshals Json and Jsons request bodies and unmarshals Response.Json.
+// It is set per client by WithCodec, or per request by UseCodec.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// StdJsonCodec is the default Codec, based on encoding/json.
+type StdJsonCodec struct {
+	// UseNumber decodes numbers into json.Number instead of float64.
+	UseNumber bool
+	// DisallowUnknownFields fails decoding into structs when the data has fields the struct doesn't.
+	DisallowUnknownFields bool
+}
+
+func (c StdJsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (c StdJsonCodec) Unmarshal(data []byte, v interface{}) error {
+	if !c.UseNumber && !c.DisallowUnknownFields {
+		return json.Unmarshal(data, v)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if c.UseNumber {
+		decoder.UseNumber()
+	}
+	if c.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	return decoder.Decode(v)
+}
+
+// UseCodec overrides the client Codec for one request and its Response.
+type UseCodec struct {
+	Codec
+}
+
+func (c UseCodec) Do(req *Request) error {
+	req.codec = c.Codec
+	return nil
+}
+
+func codecOrDefault(codec Codec) Codec {
+	if codec == nil {
+		return StdJsonCodec{}
+	}
+	return codec
+}
@@ -0,0 +1,57 @@
+package requests
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// suffixCodec appends a suffix to the encoded json, to tell it apart from the default codec.
+type suffixCodec struct{}
+
+func (suffixCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	return append(data, '\n', '#'), err
+}
+
+func (suffixCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data[:len(data)-2], v)
+}
+
+func TestCodec(t *testing.T) {
+	url := testUrl + "/post"
+	client := NewClient(WithCodec(suffixCodec{}))
+
+	resp, err := client.Post(url, Json{"a": "1"})
+	if err != nil {
+		t.Fatalf("Post() err = %v", err)
+	}
+	if got, want := resp.Text(), "{\"a\":\"1\"}\n#"; got != want {
+		t.Errorf("Post() body = %q, want %q", got, want)
+	}
+	got := map[string]interface{}{}
+	if err := resp.Json(&got); err != nil || !reflect.DeepEqual(got, map[string]interface{}{"a": "1"}) {
+		t.Errorf("Json() got = %v, err = %v", got, err)
+	}
+
+	resp, err = client.Post(url, Json{"a": 1}, UseCodec{StdJsonCodec{UseNumber: true}})
+	if err != nil {
+		t.Fatalf("Post() err = %v", err)
+	}
+	got = map[string]interface{}{}
+	if err := resp.Json(&got); err != nil || !reflect.DeepEqual(got, map[string]interface{}{"a": json.Number("1")}) {
+		t.Errorf("Json() got = %#v, err = %v", got, err)
+	}
+}
+
+func TestStdJsonCodec(t *testing.T) {
+	var v struct {
+		A int `json:"a"`
+	}
+	if err := (StdJsonCodec{}).Unmarshal([]byte(`{"a":1,"b":2}`), &v); err != nil || v.A != 1 {
+		t.Errorf("Unmarshal() got = %v, err = %v", v, err)
+	}
+	if err := (StdJsonCodec{DisallowUnknownFields: true}).Unmarshal([]byte(`{"a":1,"b":2}`), &v); err == nil {
+		t.Errorf("Unmarshal() err = nil, want unknown field error")
+	}
+}
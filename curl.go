@@ -0,0 +1,132 @@
+package requests
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Curl returns a shell escaped curl command line reproducing the request, credentials included.
+// The body is sent uncompressed when Gzip was used, Accept-Encoding is replaced by --compressed, multipart files uploaded
+// from content or a reader are referenced by their file name, and a body that cannot be read
+// twice is read from stdin.
+func (req *Request) Curl() string {
+	return req.curl(false)
+}
+
+// curl builds the command line of Curl, with the credentials masked as in LogHook if redact is set.
+func (req *Request) curl(redact bool) string {
+	u, header := req.URL.String(), req.Header
+	if redact {
+		u, header = req.redactedURL(), req.redactedHeader()
+	}
+	args := []string{"curl"}
+	switch {
+	case req.Method == HEAD:
+		args = append(args, "--head")
+	case req.Method != GET || req.hasAnyBody():
+		args = append(args, "-X", req.Method)
+	}
+	args = append(args, shellQuote(u))
+
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch key {
+		case "Content-Length", "Cookie", "Accept-Encoding":
+			continue
+		case "Content-Encoding":
+			if req.gzip {
+				continue
+			}
+		case "Content-Type":
+			if req.files != nil {
+				// set by curl with its own boundary.
+				continue
+			}
+		}
+		for _, value := range header[key] {
+			args = append(args, "-H", shellQuote(key+": "+value))
+		}
+	}
+	if cookies := header.Values("Cookie"); len(cookies) > 0 {
+		args = append(args, "-b", shellQuote(strings.Join(cookies, "; ")))
+	}
+	if req.gzip || req.Header.Get("Accept-Encoding") != "" {
+		args = append(args, "--compressed")
+	}
+
+	switch {
+	case req.files != nil:
+		for _, file := range req.files {
+			path := file.filePath
+			if path == "" {
+				path = file.name
+			}
+			args = append(args, "-F", shellQuote(file.field+"=@"+path+";filename="+file.name))
+		}
+		fields := make([]string, 0, len(req.form))
+		for key := range req.form {
+			fields = append(fields, key)
+		}
+		sort.Strings(fields)
+		for _, key := range fields {
+			args = append(args, "--form-string", shellQuote(key+"="+req.form[key]))
+		}
+	case req.GetBody != nil:
+		if body, err := req.plainBody(); err == nil {
+			args = append(args, "--data-raw", shellQuote(string(body)))
+		}
+	case req.Body != nil && req.Body != http.NoBody:
+		args = append(args, "--data-binary", "@-")
+	}
+	return strings.Join(args, " ")
+}
+
+// plainBody reads a copy of the request body, decompressed if Gzip was used.
+func (req *Request) plainBody() ([]byte, error) {
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	var reader io.Reader = body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		if reader, err = gzip.NewReader(body); err != nil {
+			return nil, err
+		}
+	}
+	return ioutil.ReadAll(reader)
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// CurlHook logs every attempt of a request as a curl command line, see Request.Curl.
+// Credentials are redacted as in LogHook.
+type CurlHook struct {
+	Logger Logger
+}
+
+func (h CurlHook) BeforeProcess(req *Request) {
+	logger := h.Logger
+	if logger == nil {
+		logger = NewStdLogger(nil)
+	}
+	logger.Log(req.Context(), LevelInfo, "go-requests", "curl", req.curl(true))
+}
+
+func (h CurlHook) AfterProcess(req *Request, resp *Response, err error) {}
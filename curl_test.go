@@ -0,0 +1,102 @@
+package requests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// buildRequest applies opts to a new request and loads its body, without sending it.
+func buildRequest(t *testing.T, method, url string, opts ...ReqOption) *Request {
+	t.Helper()
+	req, err := NewRequest(method, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opt := range opts {
+		if err := opt.Do(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := req.loadBody(); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestCurl(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		opts   []ReqOption
+		want   string
+	}{
+		{name: "get", method: GET, opts: []ReqOption{Params{"q": "a b"}}, want: `curl 'http://example.com/path?q=a+b' -H 'User-Agent: go-requests/0.1.3'`},
+		{name: "head", method: HEAD, want: `curl --head http://example.com/path -H 'User-Agent: go-requests/0.1.3'`},
+		{name: "json", method: POST, opts: []ReqOption{Json{"name": "it's"}, Header{"X-Id": "1"}},
+			want: `curl -X POST http://example.com/path -H 'Content-Type: application/json' -H 'User-Agent: go-requests/0.1.3' -H 'X-Id: 1' --data-raw '{"name":"it'\''s"}'`},
+		{name: "form gzip", method: PUT, opts: []ReqOption{Form{"a": "1"}, Gzip{}, Cookies{"s": "v"}},
+			want: `curl -X PUT http://example.com/path -H 'Content-Type: application/x-www-form-urlencoded' -H 'User-Agent: go-requests/0.1.3' -b s=v --compressed --data-raw a=1`},
+		{name: "multipart", method: POST, opts: []ReqOption{FileWithContent("file", "a.txt", []byte("x")), Form{"k": "@v"}},
+			want: `curl -X POST http://example.com/path -H 'User-Agent: go-requests/0.1.3' -F 'file=@a.txt;filename=a.txt' --form-string k=@v`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildRequest(t, tt.method, "http://example.com/path", tt.opts...).Curl(); got != tt.want {
+				t.Errorf("Curl() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurlRun(t *testing.T) {
+	if _, err := exec.LookPath("curl"); err != nil {
+		t.Skip("curl not found")
+	}
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		got = r.Method + " " + r.URL.RequestURI() + " " + r.Header.Get("Content-Type") + " " + r.Header.Get("Cookie") + " " + string(body)
+	}))
+	defer srv.Close()
+
+	req := buildRequest(t, PATCH, srv.URL+"/p", Params{"q": "$HOME"}, Json{"a": "'b' \"c\""}, Cookies{"s": "v"}, Gzip{})
+	if out, err := exec.Command("sh", "-c", req.Curl()).CombinedOutput(); err != nil {
+		t.Fatalf("curl err = %v: %s", err, out)
+	}
+	if want := `PATCH /p?q=%24HOME application/json s=v {"a":"'b' \"c\""}`; got != want {
+		t.Errorf("curl sent %v, want %v", got, want)
+	}
+	if !strings.HasPrefix(req.Curl(), "curl -X PATCH") {
+		t.Errorf("Curl() = %v", req.Curl())
+	}
+}
+
+func TestCurlHook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	logger := &recordLogger{}
+	if _, err := Get(srv.URL, Hooks{CurlHook{Logger: logger}}); err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	if got, want := logger.records[0].keyvals["curl"], "curl "+srv.URL+" -H 'User-Agent: go-requests/0.1.3' --compressed"; got != want {
+		t.Errorf("curl = %v, want %v", got, want)
+	}
+
+	// credentials are redacted in the log, not in Curl.
+	logger = &recordLogger{}
+	resp, err := Get(srv.URL, BasicAuth{Username: "user", Password: "pass"}, APIKey{Name: "key", Value: "k3y", InQuery: true}, Hooks{CurlHook{Logger: logger}})
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	if got, want := logger.records[0].keyvals["curl"], "curl '"+srv.URL+"?key=%5BREDACTED%5D' -H 'Authorization: Basic [REDACTED]' -H 'User-Agent: go-requests/0.1.3' --compressed"; got != want {
+		t.Errorf("curl = %v, want %v", got, want)
+	}
+	if got := resp.request.Curl(); !strings.Contains(got, "key=k3y") || strings.Contains(got, "REDACTED") {
+		t.Errorf("Curl() = %v, want credentials", got)
+	}
+}
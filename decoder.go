@@ -0,0 +1,116 @@
+package requests
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Decoder returns a reader decompressing r according to a Content-Encoding.
+type Decoder func(r io.Reader) (io.ReadCloser, error)
+
+// builtinCodings are the content codings decoded by default, in the order they are advertised.
+var builtinCodings = []string{"gzip", "deflate", "br", "zstd"}
+
+var builtinDecoders = map[string]Decoder{
+	"gzip":    gzipDecoder,
+	"x-gzip":  gzipDecoder,
+	"deflate": deflateDecoder,
+	"br":      brotliDecoder,
+	"zstd":    zstdDecoder,
+}
+
+// WithDecoder decodes the responses whose Content-Encoding is coding with decoder, replacing
+// the built-in gzip, deflate, br or zstd one. A nil decoder stops decoding coding. The codings
+// decoded are advertised in the Accept-Encoding header of the requests which do not set it.
+func WithDecoder(coding string, decoder Decoder) ClientOption {
+	return func(client *Client) {
+		if client.decoders == nil {
+			client.decoders = make(map[string]Decoder, len(builtinDecoders)+1)
+			for name, d := range builtinDecoders {
+				client.decoders[name] = d
+			}
+		}
+		coding = strings.ToLower(coding)
+		if decoder == nil {
+			delete(client.decoders, coding)
+			return
+		}
+		client.decoders[coding] = decoder
+	}
+}
+
+// decoderMap returns the decoders of the client.
+func (s *Client) decoderMap() map[string]Decoder {
+	if s.decoders == nil {
+		return builtinDecoders
+	}
+	return s.decoders
+}
+
+// acceptEncoding returns the Accept-Encoding header value advertising decoders.
+func acceptEncoding(decoders map[string]Decoder) string {
+	var codings []string
+	for _, coding := range builtinCodings {
+		if decoders[coding] != nil {
+			codings = append(codings, coding)
+		}
+	}
+	var custom []string
+	for coding := range decoders {
+		if _, ok := builtinDecoders[coding]; !ok {
+			custom = append(custom, coding)
+		}
+	}
+	sort.Strings(custom)
+	return strings.Join(append(codings, custom...), ", ")
+}
+
+// contentCodings returns the codings of a Content-Encoding header in the order they were applied,
+// identity is left out.
+func contentCodings(header []string) []string {
+	var codings []string
+	for _, value := range header {
+		for _, coding := range strings.Split(value, ",") {
+			if coding = strings.ToLower(strings.TrimSpace(coding)); coding != "" && coding != "identity" {
+				codings = append(codings, coding)
+			}
+		}
+	}
+	return codings
+}
+
+func gzipDecoder(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// deflateDecoder reads zlib streams, as the deflate coding is specified, and raw deflate
+// streams, which some servers send instead.
+func deflateDecoder(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+	if err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+func brotliDecoder(r io.Reader) (io.ReadCloser, error) {
+	return ioutil.NopCloser(brotli.NewReader(r)), nil
+}
+
+func zstdDecoder(r io.Reader) (io.ReadCloser, error) {
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return decoder.IOReadCloser(), nil
+}
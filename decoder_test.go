@@ -0,0 +1,133 @@
+package requests
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// encode compresses data with the codings of a Content-Encoding header, in order.
+func encode(t *testing.T, data []byte, contentEncoding string) []byte {
+	for _, coding := range contentCodings([]string{contentEncoding}) {
+		var buf bytes.Buffer
+		var w io.WriteCloser
+		switch coding {
+		case "gzip":
+			w = gzip.NewWriter(&buf)
+		case "deflate":
+			w = zlib.NewWriter(&buf)
+		case "raw-deflate":
+			w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+		case "br":
+			w = brotli.NewWriter(&buf)
+		case "zstd":
+			w, _ = zstd.NewWriter(&buf)
+		case "rot13":
+			w = nopWriteCloser{&buf}
+			data = []byte(strings.Map(rot13, string(data)))
+		default:
+			t.Fatalf("unknown coding %s", coding)
+		}
+		w.Write(data)
+		w.Close()
+		data = buf.Bytes()
+	}
+	return data
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func rot13(r rune) rune {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return 'a' + (r-'a'+13)%26
+	case r >= 'A' && r <= 'Z':
+		return 'A' + (r-'A'+13)%26
+	}
+	return r
+}
+
+func TestDecoders(t *testing.T) {
+	const content = "hello, decoded world"
+	var accept string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept-Encoding")
+		coding := r.URL.Query().Get("coding")
+		header := strings.Replace(coding, "raw-deflate", "deflate", 1)
+		w.Header().Set("Content-Encoding", header)
+		if r.Method == HEAD {
+			return
+		}
+		w.Write(encode(t, []byte(content), coding))
+	}))
+	defer srv.Close()
+
+	rot13Decoder := func(r io.Reader) (io.ReadCloser, error) {
+		data, err := ioutil.ReadAll(r)
+		return ioutil.NopCloser(strings.NewReader(strings.Map(rot13, string(data)))), err
+	}
+	custom := NewClient(WithDecoder("ROT13", rot13Decoder), WithDecoder("zstd", nil))
+
+	tests := []struct {
+		name   string
+		client *Client
+		coding string
+		opts   []ReqOption
+		want   string
+		accept string
+	}{
+		{name: "gzip", coding: "gzip", want: content, accept: "gzip, deflate, br, zstd"},
+		{name: "deflate", coding: "deflate", want: content},
+		{name: "raw deflate", coding: "raw-deflate", want: content},
+		{name: "br", coding: "br", want: content},
+		{name: "zstd", coding: "zstd", want: content},
+		{name: "case", coding: "GZip", want: content},
+		{name: "stacked", coding: "gzip, br", want: content},
+		{name: "stacked identity", coding: "zstd,identity, deflate", want: content},
+		{name: "stream", coding: "br, zstd", opts: []ReqOption{Stream{}}, want: content},
+		{name: "unknown", coding: "rot13", want: string(encode(t, []byte(content), "rot13"))},
+		{name: "custom", client: custom, coding: "rot13, gzip", want: content, accept: "gzip, deflate, br, rot13"},
+		{name: "removed", client: custom, coding: "zstd", want: string(encode(t, []byte(content), "zstd"))},
+		{name: "gzip body", client: custom, coding: "gzip", opts: []ReqOption{Gzip{}, Json{"a": 1}}, want: content, accept: "gzip, deflate, br, rot13"},
+		{name: "user accept", coding: "br", opts: []ReqOption{Header{"Accept-Encoding": "br"}}, want: content, accept: "br"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := tt.client
+			if client == nil {
+				client = NewClient()
+			}
+			resp, err := client.Get(srv.URL, append([]ReqOption{Params{"coding": tt.coding}}, tt.opts...)...)
+			if err != nil {
+				t.Fatalf("Get() err = %v", err)
+			}
+			defer resp.Close()
+			if got := resp.Text(); got != tt.want {
+				t.Errorf("Text() got = %q, want %q", got, tt.want)
+			}
+			if tt.accept != "" && accept != tt.accept {
+				t.Errorf("Accept-Encoding = %v, want %v", accept, tt.accept)
+			}
+		})
+	}
+
+	resp, err := Head(srv.URL, Params{"coding": "gzip"})
+	if err != nil {
+		t.Fatalf("Head() err = %v", err)
+	}
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Errorf("Head() Content-Encoding = %v, want gzip", resp.Header.Get("Content-Encoding"))
+	}
+}
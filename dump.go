@@ -0,0 +1,81 @@
+package requests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+)
+
+// Dump returns the request in HTTP/1.1 wire format, with its credentials redacted as in
+// LogHook. The body is read from a copy, it is left out when it cannot be read twice.
+// Headers added while sending, such as the cookies of the client jar, are not part of it.
+func (req *Request) Dump() ([]byte, error) {
+	clone := req.Request.Clone(req.Context())
+	clone.Header = req.redactedHeader()
+	u, err := url.Parse(req.redactedURL())
+	if err != nil {
+		return nil, err
+	}
+	clone.URL = u
+	dumpBody := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if dumpBody && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return httputil.DumpRequestOut(clone, dumpBody)
+}
+
+// Dump returns the response in HTTP/1.1 wire format. The body is the decoded one read
+// by NewResponse, whose Content-Encoding has been removed; it is omitted for a Stream
+// response whose body was not read yet.
+func (r *Response) Dump() ([]byte, error) {
+	clone := *r.Response
+	if r.bytes == nil {
+		clone.Body = http.NoBody
+		return httputil.DumpResponse(&clone, false)
+	}
+	clone.Body = ioutil.NopCloser(bytes.NewReader(r.bytes))
+	clone.ContentLength = int64(len(r.bytes))
+	clone.TransferEncoding = nil
+	return httputil.DumpResponse(&clone, true)
+}
+
+// WithDebug writes every attempt of a request and its response to w in HTTP/1.1 wire format,
+// see Request.Dump and Response.Dump.
+func WithDebug(w io.Writer) ClientOption {
+	return func(client *Client) {
+		client.Use(debugMiddleware(w))
+	}
+}
+
+func debugMiddleware(w io.Writer) Middleware {
+	var mu sync.Mutex
+	write := func(dump []byte, err error) {
+		if err != nil {
+			dump = []byte(fmt.Sprintf("go-requests: dump: %v\n", err))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write(append(dump, '\n'))
+	}
+	return func(next Handler) Handler {
+		return func(req *Request) (*Response, error) {
+			write(req.Dump())
+			resp, err := next(req)
+			if err != nil {
+				write(nil, err)
+			} else {
+				write(resp.Dump())
+			}
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package requests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		gw.Write([]byte("hello"))
+		gw.Close()
+	}))
+	defer srv.Close()
+
+	var debug bytes.Buffer
+	client := NewClient(WithDebug(&debug))
+	resp, err := client.Post(srv.URL+"/path", Json{"a": 1}, Header{"Accept-Encoding": "gzip"})
+	if err != nil {
+		t.Fatalf("Post() err = %v", err)
+	}
+
+	dump, err := resp.request.Dump()
+	if err != nil {
+		t.Fatalf("Request.Dump() err = %v", err)
+	}
+	for _, want := range []string{"POST /path HTTP/1.1\r\n", "Content-Type: application/json\r\n", "Content-Length: 7\r\n", "\r\n\r\n{\"a\":1}"} {
+		if !strings.Contains(string(dump), want) {
+			t.Errorf("Request.Dump() = %q, want it to contain %q", dump, want)
+		}
+	}
+
+	dump, err = resp.Dump()
+	if err != nil {
+		t.Fatalf("Response.Dump() err = %v", err)
+	}
+	if !strings.HasPrefix(string(dump), "HTTP/1.1 200 OK\r\n") || !strings.Contains(string(dump), "Content-Length: 5\r\n") || !strings.HasSuffix(string(dump), "\r\n\r\nhello") || strings.Contains(string(dump), "gzip") {
+		t.Errorf("Response.Dump() = %q", dump)
+	}
+	if resp.Text() != "hello" {
+		t.Errorf("Text() after Dump() = %v", resp.Text())
+	}
+
+	if got := debug.String(); !strings.Contains(got, "POST /path HTTP/1.1") || !strings.Contains(got, "hello") {
+		t.Errorf("WithDebug() wrote %q", got)
+	}
+}
+
+func TestDumpRedacted(t *testing.T) {
+	var debug bytes.Buffer
+	client := NewClient(WithDebug(&debug))
+	_, err := client.Get(testUrl+"/get", Bearer("s3cr3t"), APIKey{Name: "api_key", Value: "k3y", InQuery: true}, Header{"X-A": "a"})
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	// the test server echoes the query in the response, only the request is checked.
+	got := strings.SplitN(debug.String(), "\nHTTP/1.1", 2)[0]
+	if strings.Contains(got, "s3cr3t") || strings.Contains(got, "k3y") {
+		t.Errorf("WithDebug() wrote credentials %q", got)
+	}
+	for _, want := range []string{"Authorization: Bearer [REDACTED]\r\n", "api_key=%5BREDACTED%5D", "X-A: a\r\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WithDebug() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestDumpStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+	}))
+	defer srv.Close()
+
+	resp, err := Get(srv.URL, Stream{})
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	defer resp.Close()
+	dump, err := resp.Dump()
+	if err != nil {
+		t.Fatalf("Dump() err = %v", err)
+	}
+	if strings.Contains(string(dump), "body") {
+		t.Errorf("Dump() = %q, want no body", dump)
+	}
+	if got := resp.Text(); got != "body" {
+		t.Errorf("Text() after Dump() = %v", got)
+	}
+}
@@ -1,6 +1,13 @@
 package requests
 
-import "github.com/pkg/errors"
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
 
 var (
 	// ErrInvalidForm will be throw out when request form body data can not be Marshal
@@ -9,9 +16,12 @@ var (
 	// ErrInvalidJson will be throw out when request json body data can not be Marshal
 	ErrInvalidJson = errors.New("go-requests: Invalid Json value")
 
+	// ErrInvalidXml will be throw out when request xml body data can not be Marshal
+	ErrInvalidXml = errors.New("go-requests: Invalid Xml value")
+
 	// ErrUnrecognizedEncoding will be throw out while changing response encoding
 	// if encoding is not recognized
-	//ErrUnrecognizedEncoding = errors.New("go-requests: Unrecognized encoding")
+	ErrUnrecognizedEncoding = errors.New("go-requests: Unrecognized encoding")
 
 	// ErrInvalidMethod will be throw out when method not in
 	// [HEAD, GET, POST, DELETE, OPTIONS, PUT, PATCH, CONNECT, TRACE]
@@ -23,4 +33,89 @@ var (
 	ErrInvalidBodyType = errors.New("go-requests: Invalid Body Type")
 
 	ErrTimeout = errors.New("go-requests: timeout")
+
+	// ErrConnectTimeout, ErrTLSHandshakeTimeout, ErrResponseHeaderTimeout and ErrReadTimeout tell
+	// which phase of a request exceeded its timeout, the returned errors also match ErrTimeout
+	ErrConnectTimeout        = errors.New("go-requests: connect timeout")
+	ErrTLSHandshakeTimeout   = errors.New("go-requests: tls handshake timeout")
+	ErrResponseHeaderTimeout = errors.New("go-requests: response header timeout")
+	ErrReadTimeout           = errors.New("go-requests: read timeout")
+
+	// ErrHTTPStatus is wrapped by the *HTTPError returned for responses with a non-2xx status code
+	ErrHTTPStatus = errors.New("go-requests: unexpected status code")
+
+	// ErrTokenFetch will be throw out when an OAuth2 access token can not be fetched
+	ErrTokenFetch = errors.New("go-requests: failed to fetch oauth2 token")
 )
+
+// maxErrorBody is the number of response body bytes kept in an HTTPError.
+const maxErrorBody = 512
+
+// HTTPError describes a response with a non-2xx status code, it is returned by
+// Response.RaiseForStatus and wraps ErrHTTPStatus.
+type HTTPError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	// Body is the beginning of the response body, it is empty for streamed responses.
+	Body   []byte
+	Header http.Header
+}
+
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("go-requests: %s %s: %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+	if len(e.Body) > 0 {
+		msg += ": " + string(e.Body)
+	}
+	return msg
+}
+
+func (e *HTTPError) Unwrap() error {
+	return ErrHTTPStatus
+}
+
+// timeoutError is returned when a request times out or its context is done, it wraps ErrTimeout,
+// the cause, context.DeadlineExceeded or context.Canceled, and the error of the round trip.
+type timeoutError struct {
+	err   error
+	cause error
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrTimeout, e.err)
+}
+
+func (e *timeoutError) Is(target error) bool {
+	return target == ErrTimeout || target == e.cause
+}
+
+func (e *timeoutError) Unwrap() error {
+	return e.err
+}
+
+// Timeout implements net.Error.
+func (e *timeoutError) Timeout() bool {
+	return true
+}
+
+func (e *timeoutError) Temporary() bool {
+	return false
+}
+
+// timeoutErr wraps err in a timeoutError if it is caused by ctx being done or by a network timeout.
+func timeoutErr(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*timeoutError); ok {
+		return err
+	}
+	if cause := ctx.Err(); cause != nil {
+		return &timeoutError{err: err, cause: cause}
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &timeoutError{err: err, cause: context.DeadlineExceeded}
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package requests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRaiseForStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte("ok"))
+		case "/large":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(strings.Repeat("x", 2*maxErrorBody)))
+		default:
+			w.Header().Set("X-Reason", "missing")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+		}
+	}))
+	defer srv.Close()
+
+	resp, err := Get(srv.URL + "/missing")
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	err = resp.RaiseForStatus()
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || !errors.Is(err, ErrHTTPStatus) {
+		t.Fatalf("RaiseForStatus() err = %v, want *HTTPError", err)
+	}
+	if httpErr.StatusCode != 404 || httpErr.Method != GET || httpErr.URL != srv.URL+"/missing" ||
+		string(httpErr.Body) != "not found" || httpErr.Header.Get("X-Reason") != "missing" {
+		t.Errorf("RaiseForStatus() err = %+v", httpErr)
+	}
+
+	client := NewClient(WithRaiseForStatus())
+	tests := []struct {
+		name     string
+		path     string
+		opts     []ReqOption
+		wantErr  bool
+		wantBody int
+	}{
+		{name: "ok", path: "/ok"},
+		{name: "not found", path: "/missing", wantErr: true, wantBody: len("not found")},
+		{name: "truncated", path: "/large", wantErr: true, wantBody: maxErrorBody},
+		{name: "disabled", path: "/missing", opts: []ReqOption{RaiseForStatus(false)}},
+		{name: "stream", path: "/missing", opts: []ReqOption{Stream{}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Get(srv.URL+tt.path, tt.opts...)
+			if resp == nil {
+				t.Fatalf("Get() resp = nil, err = %v", err)
+			}
+			defer resp.Close()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var httpErr *HTTPError
+				if !errors.As(err, &httpErr) {
+					t.Fatalf("Get() err = %v, want *HTTPError", err)
+				}
+				if len(httpErr.Body) != tt.wantBody {
+					t.Errorf("HTTPError.Body length = %v, want %v", len(httpErr.Body), tt.wantBody)
+				}
+			}
+		})
+	}
+}
+
+func TestErrorsIs(t *testing.T) {
+	_, err := Post(testUrl+"/upload", FileWithPath("f", "./not-exists.txt"))
+	if !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("errors.Is(%v, ErrInvalidFile) = false", err)
+	}
+	_, err = Post(testUrl+"/post", Json{"a": func() {}})
+	if !errors.Is(err, ErrInvalidJson) {
+		t.Errorf("errors.Is(%v, ErrInvalidJson) = false", err)
+	}
+	_, err = Get(testUrl+"/timeout", Timeout(10e6))
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("errors.Is(%v, ErrTimeout) = false", err)
+	}
+}
@@ -1,8 +1,11 @@
 module github.com/fanjindong/go-requests
 
-go 1.12
+go 1.21
 
 require (
 	github.com/ajg/form v1.5.1
-	github.com/pkg/errors v0.8.1
+	github.com/andybalholm/brotli v1.1.1
+	github.com/klauspost/compress v1.17.11
+	github.com/pkg/errors v0.9.1
+	golang.org/x/text v0.14.0
 )
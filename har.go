@@ -0,0 +1,335 @@
+package requests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR is an HTTP Archive 1.2 document, see http://www.softwareishard.com/blog/har-12-spec/.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	// Error is the error of a request which got no response.
+	Error string `json:"_error,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type HARPostData struct {
+	MimeType string     `json:"mimeType"`
+	Text     string     `json:"text,omitempty"`
+	Params   []HARParam `json:"params,omitempty"`
+}
+
+type HARParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARTimings are in milliseconds, -1 when they do not apply.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// HARRecorder is a Hook recording every attempt of the requests of a client in HTTP Archive format.
+// The detailed timings need Trace or WithTrace, otherwise the whole duration is recorded as wait.
+type HARRecorder struct {
+	// MaxBodySize caps the recorded request and response bodies, 0 is unlimited.
+	MaxBodySize int
+
+	mu      sync.Mutex
+	entries []HAREntry
+	starts  sync.Map
+}
+
+func NewHARRecorder() *HARRecorder {
+	return &HARRecorder{}
+}
+
+func (r *HARRecorder) BeforeProcess(req *Request) {
+	r.starts.Store(req, time.Now())
+}
+
+func (r *HARRecorder) AfterProcess(req *Request, resp *Response, err error) {
+	start, duration := time.Now(), time.Duration(0)
+	if started, ok := r.starts.Load(req); ok {
+		r.starts.Delete(req)
+		start = started.(time.Time)
+		duration = time.Since(start)
+	}
+	entry := HAREntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Request:         r.request(req),
+		Response:        HARResponse{Cookies: []HARCookie{}, Headers: []HARNameValue{}, HeadersSize: -1, BodySize: -1},
+		Timings:         HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: milliseconds(duration)},
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if resp != nil {
+		entry.Response = r.response(resp)
+		if t := resp.Timings; t != nil && !t.Reused {
+			entry.Timings.DNS, entry.Timings.Connect = milliseconds(t.DNS), milliseconds(t.Connect+t.TLS)
+			if t.TLS > 0 {
+				entry.Timings.SSL = milliseconds(t.TLS)
+			}
+		}
+		if t := resp.Timings; t != nil {
+			entry.Timings.Wait, entry.Timings.Receive = milliseconds(t.Wait), milliseconds(t.ContentTransfer)
+			entry.Timings.Send = milliseconds(t.Total - t.DNS - t.Connect - t.TLS - t.Wait - t.ContentTransfer)
+			if entry.Timings.Send < 0 || t.Reused {
+				entry.Timings.Send = 0
+			}
+		}
+	}
+	for _, d := range []float64{entry.Timings.Blocked, entry.Timings.DNS, entry.Timings.Connect, entry.Timings.Send, entry.Timings.Wait, entry.Timings.Receive} {
+		if d > 0 {
+			entry.Time += d
+		}
+	}
+	r.mu.Lock()
+	r.entries = append(r.entries, entry)
+	r.mu.Unlock()
+}
+
+// HAR returns the document of the attempts recorded so far.
+func (r *HARRecorder) HAR() *HAR {
+	r.mu.Lock()
+	entries := append([]HAREntry{}, r.entries...)
+	r.mu.Unlock()
+	return &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "go-requests", Version: version},
+		Entries: entries,
+	}}
+}
+
+// WriteTo writes the HAR document as JSON.
+func (r *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(r.HAR(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// WriteFile writes the HAR document to filename.
+func (r *HARRecorder) WriteFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if _, err = r.WriteTo(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// request records req with its credentials redacted as in LogHook.
+func (r *HARRecorder) request(req *Request) HARRequest {
+	h := HARRequest{
+		Method:      req.Method,
+		URL:         req.redactedURL(),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []HARCookie{},
+		Headers:     harHeaders(req.redactedHeader()),
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    req.ContentLength,
+	}
+	for _, cookie := range req.Cookies() {
+		h.Cookies = append(h.Cookies, HARCookie{Name: cookie.Name, Value: cookie.Value})
+	}
+	var query url.Values
+	if u, err := url.Parse(h.URL); err == nil {
+		query = u.Query()
+	}
+	for _, name := range sortedKeys(query) {
+		for _, value := range query[name] {
+			h.QueryString = append(h.QueryString, HARNameValue{Name: name, Value: value})
+		}
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		h.BodySize = 0
+		return h
+	}
+	mimeType := req.Header.Get("Content-Type")
+	h.PostData = &HARPostData{MimeType: mimeType}
+	if req.files != nil || req.multipart {
+		names := make([]string, 0, len(req.form))
+		for name := range req.form {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			h.PostData.Params = append(h.PostData.Params, HARParam{Name: name, Value: req.form[name]})
+		}
+		for _, file := range req.files {
+			h.PostData.Params = append(h.PostData.Params, HARParam{Name: file.field, FileName: file.name, ContentType: "application/octet-stream"})
+		}
+		return h
+	}
+	if req.GetBody == nil {
+		return h
+	}
+	data, err := req.plainBody()
+	if err != nil {
+		return h
+	}
+	if mediaType, _, _ := mime.ParseMediaType(mimeType); mediaType == "application/x-www-form-urlencoded" {
+		if values, err := url.ParseQuery(string(data)); err == nil {
+			for _, name := range sortedKeys(values) {
+				for _, value := range values[name] {
+					h.PostData.Params = append(h.PostData.Params, HARParam{Name: name, Value: value})
+				}
+			}
+		}
+	}
+	h.PostData.Text, _ = r.text(data)
+	return h
+}
+
+func (r *HARRecorder) response(resp *Response) HARResponse {
+	h := HARResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     []HARCookie{},
+		Headers:     harHeaders(resp.Header),
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    resp.ContentLength,
+	}
+	for _, cookie := range resp.Cookies() {
+		c := HARCookie{Name: cookie.Name, Value: cookie.Value, Path: cookie.Path, Domain: cookie.Domain, HTTPOnly: cookie.HttpOnly, Secure: cookie.Secure}
+		if !cookie.Expires.IsZero() {
+			c.Expires = cookie.Expires.Format(time.RFC3339)
+		}
+		h.Cookies = append(h.Cookies, c)
+	}
+	h.Content = HARContent{Size: resp.ContentLength, MimeType: resp.Header.Get("Content-Type")}
+	if resp.bytes != nil {
+		h.Content.Size = int64(len(resp.bytes))
+		h.Content.Text, h.Content.Encoding = r.text(resp.bytes)
+		if h.BodySize < 0 {
+			h.BodySize = h.Content.Size
+		}
+	}
+	return h
+}
+
+// text returns data capped to MaxBodySize, base64 encoded if it is not valid UTF-8.
+func (r *HARRecorder) text(data []byte) (text, encoding string) {
+	if r.MaxBodySize > 0 && len(data) > r.MaxBodySize {
+		data = data[:r.MaxBodySize]
+	}
+	if !utf8.Valid(data) {
+		return base64.StdEncoding.EncodeToString(data), "base64"
+	}
+	return string(data), ""
+}
+
+func harHeaders(header http.Header) []HARNameValue {
+	headers := []HARNameValue{}
+	for _, name := range sortedKeys(header) {
+		for _, value := range header[name] {
+			headers = append(headers, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,87 @@
+package requests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHARRecorder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s", Path: "/", HttpOnly: true})
+		w.Header().Set("Content-Type", "text/plain")
+		if r.URL.Path == "/binary" {
+			w.Write([]byte{0xff, 0xfe, 0x00})
+			return
+		}
+		w.Write([]byte("hello world"))
+	}))
+	defer srv.Close()
+
+	recorder := NewHARRecorder()
+	recorder.MaxBodySize = 5
+	client := NewClient(WithTrace())
+	client.AddHook(recorder)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client.Get(srv.URL + "/concurrent")
+		}()
+	}
+	wg.Wait()
+	client.Post(srv.URL+"/form", Params{"q": "1"}, Form{"a": "1"}, Cookies{"c": "v"})
+	client.Post(srv.URL+"/json", Json{"a": 1})
+	client.Post(srv.URL+"/upload", FileWithContent("file", "a.txt", []byte("x")), Form{"k": "v"})
+	client.Get(srv.URL + "/binary")
+	client.Get("http://127.0.0.1:1/down")
+	client.Get(srv.URL+"/secret", Bearer("s3cr3t"), APIKey{Name: "key", Value: "k3y", InQuery: true})
+
+	var buf bytes.Buffer
+	if _, err := recorder.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() err = %v", err)
+	}
+	var har HAR
+	if err := json.Unmarshal(buf.Bytes(), &har); err != nil {
+		t.Fatalf("Unmarshal() err = %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 11 {
+		t.Fatalf("HAR version = %v, entries = %v", har.Log.Version, len(har.Log.Entries))
+	}
+
+	form := har.Log.Entries[5]
+	if form.Request.Method != POST || form.Request.QueryString[0] != (HARNameValue{Name: "q", Value: "1"}) || form.Request.Cookies[0].Name != "c" {
+		t.Errorf("form request = %+v", form.Request)
+	}
+	if post := form.Request.PostData; post.MimeType != "application/x-www-form-urlencoded" || post.Text != "a=1" || post.Params[0] != (HARParam{Name: "a", Value: "1"}) {
+		t.Errorf("form postData = %+v", post)
+	}
+	if resp := form.Response; resp.Status != 200 || resp.Content.Text != "hello" || resp.Content.Size != 11 || resp.Cookies[0].Name != "session" || !resp.Cookies[0].HTTPOnly {
+		t.Errorf("form response = %+v", resp)
+	}
+	if form.Timings.Wait < 0 || form.Timings.Receive < 0 || form.Time <= 0 {
+		t.Errorf("form timings = %+v, time = %v", form.Timings, form.Time)
+	}
+	if got := har.Log.Entries[6].Request.PostData.Text; got != `{"a":` {
+		t.Errorf("json postData = %v", got)
+	}
+	if got := har.Log.Entries[7].Request.PostData.Params; len(got) != 2 || got[0].Name != "k" || got[1].FileName != "a.txt" {
+		t.Errorf("multipart params = %+v", got)
+	}
+	if got := har.Log.Entries[8].Response.Content; got.Encoding != "base64" || got.Text != "//4A" {
+		t.Errorf("binary content = %+v", got)
+	}
+	if got := har.Log.Entries[9]; got.Error == "" || got.Response.Status != 0 {
+		t.Errorf("failed entry = %+v", got)
+	}
+	if got := har.Log.Entries[10].Request; strings.Contains(buf.String(), "s3cr3t") || strings.Contains(buf.String(), "k3y") ||
+		got.QueryString[0].Value != "[REDACTED]" || !strings.Contains(got.URL, "key=%5BREDACTED%5D") {
+		t.Errorf("secret request = %+v", got)
+	}
+}
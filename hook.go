@@ -1,8 +1,35 @@
 package requests
 
+// Handler sends a request and returns its response.
+type Handler func(req *Request) (*Response, error)
+
+// Middleware wraps the Handler sending a request. It can modify the request, return a
+// response without calling next, e.g. from a cache, or replace the response and the error.
+type Middleware func(next Handler) Handler
+
 type Hook interface {
 	// BeforeProcess Before the HTTP request is executed
 	BeforeProcess(req *Request)
 	// AfterProcess After the HTTP request is executed
 	AfterProcess(req *Request, resp *Response, err error)
 }
+
+// Hooks runs hooks around every attempt of a single request, after the client middlewares and hooks.
+type Hooks []Hook
+
+func (h Hooks) Do(req *Request) error {
+	req.hooks = append(req.hooks, h...)
+	return nil
+}
+
+// HookMiddleware adapts h to a Middleware, BeforeProcess runs before next and AfterProcess after it.
+func HookMiddleware(h Hook) Middleware {
+	return func(next Handler) Handler {
+		return func(req *Request) (*Response, error) {
+			h.BeforeProcess(req)
+			resp, err := next(req)
+			h.AfterProcess(req, resp, err)
+			return resp, err
+		}
+	}
+}
@@ -1,7 +1,15 @@
 package requests
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 )
 
@@ -21,3 +29,195 @@ func TestHook(t *testing.T) {
 	client.AddHook(mockHook{})
 	t.Log(client.Get(testUrl))
 }
+
+func TestMiddleware(t *testing.T) {
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(req *Request) (*Response, error) {
+				order = append(order, name+" before")
+				resp, err := next(req)
+				order = append(order, name+" after")
+				return resp, err
+			}
+		}
+	}
+	setHeader := func(next Handler) Handler {
+		return func(req *Request) (*Response, error) {
+			req.Header.Set("X-Middleware", "1")
+			return next(req)
+		}
+	}
+	cached := func(next Handler) Handler {
+		return func(req *Request) (*Response, error) {
+			return &Response{Response: &http.Response{StatusCode: http.StatusOK}, bytes: []byte("cached")}, nil
+		}
+	}
+	errBlocked := errors.New("blocked")
+	block := func(next Handler) Handler {
+		return func(req *Request) (*Response, error) {
+			resp, err := next(req)
+			if err == nil && resp.Text() == "1" {
+				return nil, errBlocked
+			}
+			return resp, err
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("X-Middleware")))
+	}))
+	defer srv.Close()
+
+	client := NewClient(WithMiddleware(trace("a"), setHeader))
+	client.Use(trace("b"))
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	if got := resp.Text(); got != "1" {
+		t.Errorf("modified request got = %v, want 1", got)
+	}
+	if want := []string{"a before", "b before", "b after", "a after"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	resp, err = NewClient(WithMiddleware(cached)).Get("http://invalid.invalid")
+	if err != nil {
+		t.Fatalf("cached Get() err = %v", err)
+	}
+	if got := resp.Text(); got != "cached" {
+		t.Errorf("cached got = %v, want cached", got)
+	}
+
+	_, err = NewClient(WithMiddleware(block, setHeader)).Get(srv.URL)
+	if !errors.Is(err, errBlocked) {
+		t.Errorf("blocked err = %v, want %v", err, errBlocked)
+	}
+}
+
+// closeCounter is a request body counting its Close calls.
+type closeCounter struct {
+	io.Reader
+	closes int32
+}
+
+func (c *closeCounter) Close() error {
+	atomic.AddInt32(&c.closes, 1)
+	return nil
+}
+
+func TestUnsentBodyClosed(t *testing.T) {
+	cached := func(next Handler) Handler {
+		return func(req *Request) (*Response, error) {
+			return &Response{Response: &http.Response{StatusCode: http.StatusOK}, bytes: []byte("cached")}, nil
+		}
+	}
+	failing := func(next Handler) Handler {
+		return func(req *Request) (*Response, error) {
+			return nil, errors.New("failing")
+		}
+	}
+	tests := []struct {
+		name   string
+		client *Client
+	}{
+		{name: "sent", client: NewClient()},
+		{name: "cached", client: NewClient(WithMiddleware(cached))},
+		{name: "failing", client: NewClient(WithMiddleware(failing))},
+		{name: "authorize error", client: NewClient(WithOAuth2(OAuth2{TokenURL: "http://127.0.0.1:1/token"}))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := &closeCounter{Reader: strings.NewReader("body")}
+			tt.client.Post(testUrl+"/post", Body(body, "text/plain"))
+			if closes := atomic.LoadInt32(&body.closes); closes != 1 {
+				t.Errorf("body closed %v times, want 1", closes)
+			}
+		})
+	}
+}
+
+type recordHook struct {
+	name  string
+	order *[]string
+}
+
+func (h *recordHook) BeforeProcess(req *Request) { *h.order = append(*h.order, h.name) }
+
+func (h *recordHook) AfterProcess(req *Request, resp *Response, err error) {}
+
+func TestRequestHooks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var order []string
+	a, b, c := &recordHook{"a", &order}, &recordHook{"b", &order}, &recordHook{"c", &order}
+	client := NewClient()
+	client.AddHook(a)
+	client.AddHook(b)
+
+	tests := []struct {
+		name  string
+		setup func()
+		opts  []ReqOption
+		want  []string
+	}{
+		{name: "client", want: []string{"a", "b"}},
+		{name: "request", opts: []ReqOption{Hooks{c}}, want: []string{"a", "b", "c"}},
+		{name: "request only once", want: []string{"a", "b"}},
+		{name: "replace", setup: func() { client.ReplaceHook(a, c) }, want: []string{"c", "b"}},
+		{name: "remove", setup: func() { client.RemoveHook(c) }, want: []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setup != nil {
+				tt.setup()
+			}
+			order = nil
+			if _, err := client.Get(srv.URL, tt.opts...); err != nil {
+				t.Fatalf("Get() err = %v", err)
+			}
+			if !reflect.DeepEqual(order, tt.want) {
+				t.Errorf("hooks ran %v, want %v", order, tt.want)
+			}
+		})
+	}
+	if client.RemoveHook(a) {
+		t.Errorf("RemoveHook() of a removed hook = true")
+	}
+	if got := client.Hooks(); !reflect.DeepEqual(got, []Hook{b}) {
+		t.Errorf("Hooks() = %v", got)
+	}
+}
+
+type nopHook struct{ id int }
+
+func (nopHook) BeforeProcess(req *Request) {}
+
+func (nopHook) AfterProcess(req *Request, resp *Response, err error) {}
+
+func TestHookConcurrency(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client := NewClient()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			h := nopHook{id: i}
+			client.AddHook(h)
+			client.RemoveHook(h)
+		}(i)
+		go func() {
+			defer wg.Done()
+			client.Get(srv.URL)
+		}()
+	}
+	wg.Wait()
+	if hooks := client.Hooks(); len(hooks) != 0 {
+		t.Errorf("Hooks() = %v, want none", hooks)
+	}
+}
@@ -0,0 +1,244 @@
+package requests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel is the severity of a log record, its values match the levels of log/slog.
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = -4
+	LevelInfo  LogLevel = 0
+	LevelWarn  LogLevel = 4
+	LevelError LogLevel = 8
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// Logger writes a log record made of a message and alternating keys and values.
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{})
+}
+
+// NewStdLogger adapts l to a Logger writing "LEVEL msg key=value ..." lines, a nil l writes to stderr.
+func NewStdLogger(l *log.Logger) Logger {
+	if l == nil {
+		l = log.New(os.Stderr, "", log.LstdFlags)
+	}
+	return stdLogger{l}
+}
+
+type stdLogger struct {
+	*log.Logger
+}
+
+func (l stdLogger) Log(_ context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		fmt.Fprintf(&b, " %v=%q", keyvals[i], fmt.Sprint(keyvals[i+1]))
+	}
+	l.Print(b.String())
+}
+
+// DefaultRedactedHeaders are the headers masked by LogHook when RedactHeaders is nil.
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+const defaultLogBodySize = 4096
+
+// LogHook logs every attempt of a request with its method, url, status, duration and sizes.
+// Headers set by credential options such as APIKey are always redacted.
+type LogHook struct {
+	Logger Logger
+	// Headers and Bodies also log the request and response headers and bodies.
+	Headers bool
+	Bodies  bool
+	// MaxBodySize truncates the logged bodies, default 4096 bytes, negative is unlimited.
+	MaxBodySize int
+	// RedactHeaders are the headers masked, default DefaultRedactedHeaders.
+	RedactHeaders []string
+	// RedactFields are the JSON fields, at any depth, and form values masked in the logged bodies.
+	RedactFields []string
+
+	starts sync.Map
+}
+
+// NewLogHook returns a LogHook writing to logger.
+func NewLogHook(logger Logger) *LogHook {
+	return &LogHook{Logger: logger}
+}
+
+func (h *LogHook) BeforeProcess(req *Request) {
+	h.starts.Store(req, time.Now())
+}
+
+func (h *LogHook) AfterProcess(req *Request, resp *Response, err error) {
+	var duration time.Duration
+	if start, ok := h.starts.Load(req); ok {
+		h.starts.Delete(req)
+		duration = time.Since(start.(time.Time))
+	}
+	keyvals := []interface{}{
+		"method", req.Method,
+		"url", req.redactedURL(),
+		"attempt", req.attempt,
+		"duration", duration,
+		"request_size", req.ContentLength,
+	}
+	if h.Headers {
+		keyvals = append(keyvals, "request_headers", h.redactHeader(req.redactedHeader()))
+	}
+	if h.Bodies {
+		keyvals = append(keyvals, "request_body", h.requestBody(req))
+	}
+	level := LevelInfo
+	if err != nil {
+		level = LevelError
+		keyvals = append(keyvals, "error", err.Error())
+	}
+	if resp != nil {
+		if resp.StatusCode >= 400 && level < LevelWarn {
+			level = LevelWarn
+		}
+		size := resp.ContentLength
+		if resp.bytes != nil {
+			size = int64(len(resp.bytes))
+		}
+		keyvals = append(keyvals, "status", resp.StatusCode, "response_size", size)
+		if h.Headers {
+			keyvals = append(keyvals, "response_headers", h.redactHeader(resp.Header.Clone()))
+		}
+		if h.Bodies && resp.bytes != nil {
+			keyvals = append(keyvals, "response_body", h.body(resp.bytes, resp.Header.Get("Content-Type")))
+		}
+	}
+	logger := h.Logger
+	if logger == nil {
+		logger = NewStdLogger(nil)
+	}
+	logger.Log(req.Context(), level, "go-requests", keyvals...)
+}
+
+// redactHeader masks the RedactHeaders of header in place.
+func (h *LogHook) redactHeader(header http.Header) http.Header {
+	keys := h.RedactHeaders
+	if keys == nil {
+		keys = DefaultRedactedHeaders
+	}
+	return redactHeader(header, keys)
+}
+
+// requestBody returns the logged request body, multipart bodies are not read.
+func (h *LogHook) requestBody(req *Request) string {
+	contentType := req.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "multipart/") {
+		return "[multipart body]"
+	}
+	if req.GetBody == nil {
+		return ""
+	}
+	data, err := req.plainBody()
+	if err != nil {
+		return ""
+	}
+	return h.body(data, contentType)
+}
+
+// body redacts the RedactFields of data and truncates it to MaxBodySize.
+func (h *LogHook) body(data []byte, contentType string) string {
+	if len(h.RedactFields) > 0 {
+		data = redactFields(data, contentType, h.RedactFields)
+	}
+	max := h.MaxBodySize
+	if max == 0 {
+		max = defaultLogBodySize
+	}
+	if max > 0 && len(data) > max {
+		return fmt.Sprintf("%s... [%d bytes truncated]", data[:max], len(data)-max)
+	}
+	return string(data)
+}
+
+// redactFields masks the fields of a JSON or urlencoded form body, other bodies are returned as is.
+func redactFields(data []byte, contentType string, fields []string) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return data
+		}
+		for key := range values {
+			if containsFold(fields, key) {
+				values[key] = []string{redacted}
+			}
+		}
+		return []byte(values.Encode())
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&v); err != nil {
+			return data
+		}
+		out, err := json.Marshal(redactJson(v, fields))
+		if err != nil {
+			return data
+		}
+		return out
+	}
+	return data
+}
+
+func redactJson(v interface{}, fields []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if containsFold(fields, key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactJson(value, fields)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactJson(value, fields)
+		}
+	}
+	return v
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
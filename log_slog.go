@@ -0,0 +1,22 @@
+package requests
+
+import (
+	"context"
+	"log/slog"
+)
+
+// NewSlogLogger adapts l to a Logger, a nil l uses slog.Default.
+func NewSlogLogger(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return slogLogger{l}
+}
+
+type slogLogger struct {
+	*slog.Logger
+}
+
+func (l slogLogger) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	l.Logger.Log(ctx, slog.Level(level), msg, keyvals...)
+}
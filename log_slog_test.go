@@ -0,0 +1,24 @@
+package requests
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	NewSlogLogger(slog.New(handler)).Log(context.Background(), LevelWarn, "go-requests", "method", "GET", "status", 404)
+	if got, want := buf.String(), "level=WARN msg=go-requests method=GET status=404\n"; got != want {
+		t.Errorf("Log() got = %q, want %q", got, want)
+	}
+}
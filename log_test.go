@@ -0,0 +1,96 @@
+package requests
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type logRecord struct {
+	level   LogLevel
+	msg     string
+	keyvals map[string]interface{}
+}
+
+type recordLogger struct {
+	records []logRecord
+}
+
+func (l *recordLogger) Log(_ context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	record := logRecord{level: level, msg: msg, keyvals: map[string]interface{}{}}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		record.keyvals[keyvals[i].(string)] = keyvals[i+1]
+	}
+	l.records = append(l.records, record)
+}
+
+func TestLogHook(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{"token":"abc","data":{"password":"p","name":"n"},"padding":"0123456789"}`))
+	}))
+	defer srv.Close()
+
+	logger := &recordLogger{}
+	hook := NewLogHook(logger)
+	hook.Headers, hook.Bodies = true, true
+	hook.RedactFields = []string{"password", "token"}
+	client := NewClient(WithAuth(Bearer("secret")))
+	client.AddHook(hook)
+
+	_, err := client.Post(srv.URL+"/login", Json{"user": "u", "password": "p"}, Cookies{"a": "b"}, APIKey{Name: "X-Key", Value: "k"})
+	if err != nil {
+		t.Fatalf("Post() err = %v", err)
+	}
+	record := logger.records[0]
+	if record.level != LevelInfo || record.keyvals["method"] != "POST" || record.keyvals["url"] != srv.URL+"/login" || record.keyvals["status"] != 200 {
+		t.Errorf("record = %+v", record)
+	}
+	if record.keyvals["request_size"].(int64) != 27 {
+		t.Errorf("request_size = %v, want 27", record.keyvals["request_size"])
+	}
+	headers := record.keyvals["request_headers"].(http.Header)
+	if headers.Get("Authorization") != "Bearer "+redacted || headers.Get("Cookie") != redacted || headers.Get("X-Key") != redacted {
+		t.Errorf("request_headers = %v", headers)
+	}
+	if got := record.keyvals["response_headers"].(http.Header).Get("Set-Cookie"); got != redacted {
+		t.Errorf("Set-Cookie = %v", got)
+	}
+	if got, want := record.keyvals["request_body"], `{"password":"[REDACTED]","user":"u"}`; got != want {
+		t.Errorf("request_body = %v, want %v", got, want)
+	}
+	if got, want := record.keyvals["response_body"], `{"data":{"name":"n","password":"[REDACTED]"},"padding":"0123456789","token":"[REDACTED]"}`; got != want {
+		t.Errorf("response_body = %v, want %v", got, want)
+	}
+
+	hook.MaxBodySize = 10
+	_, _ = client.Get(srv.URL + "/missing")
+	record = logger.records[1]
+	if record.level != LevelWarn || record.keyvals["status"] != http.StatusNotFound {
+		t.Errorf("record = %+v", record)
+	}
+	if got := record.keyvals["response_body"].(string); !strings.HasPrefix(got, `{"data":{"... [`) {
+		t.Errorf("response_body = %v", got)
+	}
+
+	_, _ = Get("http://127.0.0.1:1", Hooks{hook})
+	if record = logger.records[2]; record.level != LevelError || record.keyvals["error"] == nil {
+		t.Errorf("record = %+v", record)
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	NewStdLogger(log.New(&buf, "", 0)).Log(context.Background(), LevelWarn, "go-requests", "method", "GET", "status", 404)
+	if got, want := buf.String(), "WARN go-requests method=\"GET\" status=\"404\"\n"; got != want {
+		t.Errorf("Log() got = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,332 @@
+// Package mock provides an http.RoundTripper answering requests with stubs, so that code
+// calling go-requests can be tested without a server:
+//
+//	transport := mock.New()
+//	transport.On("GET", "https://api.example.com/users/*").ReplyJSON(200, map[string]string{"name": "n"})
+//	defer transport.Install(requests.DefaultClient)()
+//	...
+//	transport.Verify(t)
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	requests "github.com/fanjindong/go-requests"
+	"github.com/pkg/errors"
+)
+
+// ErrNoStub will be throw out when no stub matches a request
+var ErrNoStub = errors.New("go-requests/mock: no stub matches the request")
+
+// Transport is an http.RoundTripper answering requests with the first matching stub.
+type Transport struct {
+	mu        sync.Mutex
+	stubs     []*Stub
+	unmatched []*http.Request
+}
+
+func New() *Transport {
+	return &Transport{}
+}
+
+// On registers a stub for method, any method if empty, and the url pattern, in which * matches
+// any characters. The pattern is matched against the url without its query, see Stub.Query.
+func (t *Transport) On(method, pattern string) *Stub {
+	quoted := strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1)
+	s := &Stub{
+		transport: t,
+		method:    strings.ToUpper(method),
+		pattern:   pattern,
+		url:       regexp.MustCompile("^" + quoted + "$"),
+		status:    http.StatusOK,
+		header:    http.Header{},
+	}
+	t.mu.Lock()
+	t.stubs = append(t.stubs, s)
+	t.mu.Unlock()
+	return s
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	var stub *Stub
+	for _, s := range t.stubs {
+		if (s.times == 0 || s.calls < s.times) && s.match(req, body) {
+			stub = s
+			break
+		}
+	}
+	if stub == nil {
+		t.unmatched = append(t.unmatched, req)
+		t.mu.Unlock()
+		return nil, errors.Wrapf(ErrNoStub, "%s %s", req.Method, req.URL)
+	}
+	stub.calls++
+	status, header, replyBody, replyErr, delay := stub.status, stub.header.Clone(), stub.body, stub.err, stub.delay
+	t.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	if replyErr != nil {
+		return nil, replyErr
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(replyBody)),
+		ContentLength: int64(len(replyBody)),
+		Request:       req,
+	}, nil
+}
+
+// Install makes client send its requests through t, until restore is called. The http.Client
+// of client is replaced rather than modified, so installing on requests.DefaultClient leaves
+// http.DefaultClient untouched. As with the fields of an http.Client, Install and restore must
+// not be called while client is sending requests; use requests.WithTransport(t) on a client of
+// its own for tests running in parallel.
+func (t *Transport) Install(client *requests.Client) (restore func()) {
+	original := client.Client
+	mocked := *original
+	mocked.Transport = t
+	client.Client = &mocked
+	return func() { client.Client = original }
+}
+
+// Unmatched returns the requests no stub matched.
+func (t *Transport) Unmatched() []*http.Request {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*http.Request(nil), t.unmatched...)
+}
+
+// Unused returns the stubs never called.
+func (t *Transport) Unused() []*Stub {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var unused []*Stub
+	for _, s := range t.stubs {
+		if s.calls == 0 {
+			unused = append(unused, s)
+		}
+	}
+	return unused
+}
+
+// TB is the part of testing.TB used by Verify.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Verify reports the unmatched requests and the unused stubs as test errors.
+func (t *Transport) Verify(tb TB) {
+	tb.Helper()
+	for _, req := range t.Unmatched() {
+		tb.Errorf("go-requests/mock: unmatched request %s %s", req.Method, req.URL)
+	}
+	for _, s := range t.Unused() {
+		tb.Errorf("go-requests/mock: unused stub %s", s)
+	}
+}
+
+// Stub describes the requests it matches and the response it returns. Its methods may be
+// called while the transport is in use, a request sees the stub as it was when it matched.
+type Stub struct {
+	transport *Transport
+	method    string
+	pattern   string
+	url       *regexp.Regexp
+	query     map[string]string
+	headers   map[string]string
+	json      interface{}
+	hasJson   bool
+
+	status int
+	header http.Header
+	body   []byte
+	err    error
+	delay  time.Duration
+	times  int
+	calls  int
+}
+
+// Query matches requests with the query parameter key equal to value.
+func (s *Stub) Query(key, value string) *Stub {
+	s.transport.mu.Lock()
+	defer s.transport.mu.Unlock()
+	if s.query == nil {
+		s.query = map[string]string{}
+	}
+	s.query[key] = value
+	return s
+}
+
+// Header matches requests with the header key equal to value.
+func (s *Stub) Header(key, value string) *Stub {
+	s.transport.mu.Lock()
+	defer s.transport.mu.Unlock()
+	if s.headers == nil {
+		s.headers = map[string]string{}
+	}
+	s.headers[key] = value
+	return s
+}
+
+// JSON matches requests whose JSON body equals v once both are decoded.
+func (s *Stub) JSON(v interface{}) *Stub {
+	s.transport.mu.Lock()
+	defer s.transport.mu.Unlock()
+	s.json, s.hasJson = normalizeJson(v), true
+	return s
+}
+
+// Reply returns a response with status and body.
+func (s *Stub) Reply(status int, body string) *Stub {
+	s.transport.mu.Lock()
+	defer s.transport.mu.Unlock()
+	s.status, s.body = status, []byte(body)
+	return s
+}
+
+// ReplyJSON returns a response with status and v encoded as JSON.
+func (s *Stub) ReplyJSON(status int, v interface{}) *Stub {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("go-requests/mock: ReplyJSON: %v", err))
+	}
+	s.transport.mu.Lock()
+	defer s.transport.mu.Unlock()
+	s.status, s.body = status, body
+	s.header.Set("Content-Type", "application/json")
+	return s
+}
+
+// ReplyHeader adds a header to the response.
+func (s *Stub) ReplyHeader(key, value string) *Stub {
+	s.transport.mu.Lock()
+	defer s.transport.mu.Unlock()
+	s.header.Add(key, value)
+	return s
+}
+
+// ReplyError fails the requests with err.
+func (s *Stub) ReplyError(err error) *Stub {
+	s.transport.mu.Lock()
+	defer s.transport.mu.Unlock()
+	s.err = err
+	return s
+}
+
+// Delay waits for d, or until the request is canceled, before replying.
+func (s *Stub) Delay(d time.Duration) *Stub {
+	s.transport.mu.Lock()
+	defer s.transport.mu.Unlock()
+	s.delay = d
+	return s
+}
+
+// Times limits the stub to n calls, the following requests fall through to the next stubs.
+func (s *Stub) Times(n int) *Stub {
+	s.transport.mu.Lock()
+	defer s.transport.mu.Unlock()
+	s.times = n
+	return s
+}
+
+// Calls returns how many requests the stub answered.
+func (s *Stub) Calls() int {
+	s.transport.mu.Lock()
+	defer s.transport.mu.Unlock()
+	return s.calls
+}
+
+func (s *Stub) String() string {
+	method := s.method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + s.pattern
+}
+
+func (s *Stub) match(req *http.Request, body []byte) bool {
+	if s.method != "" && s.method != req.Method {
+		return false
+	}
+	u := *req.URL
+	u.RawQuery, u.Fragment = "", ""
+	if !s.url.MatchString(u.String()) {
+		return false
+	}
+	query := req.URL.Query()
+	for key, value := range s.query {
+		if query.Get(key) != value {
+			return false
+		}
+	}
+	for key, value := range s.headers {
+		if req.Header.Get(key) != value {
+			return false
+		}
+	}
+	if s.hasJson {
+		var v interface{}
+		if json.Unmarshal(body, &v) != nil || !reflect.DeepEqual(v, s.json) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeJson returns v as decoded from JSON, so that it compares with reflect.DeepEqual.
+func normalizeJson(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("go-requests/mock: JSON: %v", err))
+	}
+	var normalized interface{}
+	_ = json.Unmarshal(data, &normalized)
+	return normalized
+}
+
+// readBody reads a copy of the body of req through GetBody, or else consumes and closes it
+// as a RoundTripper does, without modifying req.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		_ = req.Body.Close()
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+	defer req.Body.Close()
+	return ioutil.ReadAll(req.Body)
+}
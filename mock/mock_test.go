@@ -0,0 +1,137 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	requests "github.com/fanjindong/go-requests"
+	"github.com/pkg/errors"
+)
+
+type recordTB struct {
+	errors []string
+}
+
+func (r *recordTB) Helper() {}
+
+func (r *recordTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestTransport(t *testing.T) {
+	transport := New()
+	user := transport.On("GET", "https://api.example.com/users/*").Query("fields", "name").ReplyJSON(200, map[string]string{"name": "n"})
+	created := transport.On("post", "https://api.example.com/users").Header("X-Id", "1").JSON(map[string]int{"age": 1}).Reply(201, "created").ReplyHeader("Location", "/users/2")
+	flaky := transport.On("", "https://api.example.com/flaky").Times(1).ReplyError(errors.New("boom"))
+	transport.On("", "https://api.example.com/flaky").Reply(200, "ok")
+	unused := transport.On("DELETE", "https://api.example.com/users/*")
+
+	client := requests.NewClient(requests.WithTransport(transport))
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		opts   []requests.ReqOption
+		status int
+		want   string
+		err    bool
+	}{
+		{name: "pattern query", method: requests.GET, url: "https://api.example.com/users/1?fields=name", status: 200, want: `{"name":"n"}`},
+		{name: "query mismatch", method: requests.GET, url: "https://api.example.com/users/1", err: true},
+		{name: "json header", method: requests.POST, url: "https://api.example.com/users", opts: []requests.ReqOption{requests.Json{"age": 1}, requests.Header{"X-Id": "1"}}, status: 201, want: "created"},
+		{name: "json mismatch", method: requests.POST, url: "https://api.example.com/users", opts: []requests.ReqOption{requests.Json{"age": 2}, requests.Header{"X-Id": "1"}}, err: true},
+		{name: "error once", method: requests.GET, url: "https://api.example.com/flaky", err: true},
+		{name: "then ok", method: requests.GET, url: "https://api.example.com/flaky", status: 200, want: "ok"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Request(tt.method, tt.url, tt.opts...)
+			if (err != nil) != tt.err {
+				t.Fatalf("Request() err = %v, want err %v", err, tt.err)
+			}
+			if err == nil && (resp.StatusCode != tt.status || resp.Text() != tt.want) {
+				t.Errorf("Request() got = %v %v, want %v %v", resp.StatusCode, resp.Text(), tt.status, tt.want)
+			}
+		})
+	}
+	if user.Calls() != 1 || created.Calls() != 1 || flaky.Calls() != 1 {
+		t.Errorf("Calls() = %v %v %v, want 1 1 1", user.Calls(), created.Calls(), flaky.Calls())
+	}
+
+	tb := &recordTB{}
+	transport.Verify(tb)
+	if len(tb.errors) != 3 || len(transport.Unmatched()) != 2 || transport.Unused()[0] != unused {
+		t.Errorf("Verify() reported %v", tb.errors)
+	}
+	if _, err := client.Get("https://other.example.com"); !errors.Is(err, ErrNoStub) {
+		t.Errorf("unmatched err = %v, want ErrNoStub", err)
+	}
+}
+
+func TestTransportDelay(t *testing.T) {
+	transport := New()
+	transport.On("GET", "http://example.com").Delay(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	client := requests.NewClient(requests.WithTransport(transport))
+	if _, err := client.Get("http://example.com", requests.Ctx{Context: ctx}); !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, requests.ErrTimeout) {
+		t.Errorf("Get() err = %v, want a timeout", err)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	transport := New()
+	transport.On("GET", "http://example.com/").Reply(200, "mocked")
+	restore := transport.Install(requests.DefaultClient)
+	resp, err := requests.Get("http://example.com/")
+	if err != nil || resp.Text() != "mocked" {
+		t.Errorf("Get() got = %v, err = %v", resp, err)
+	}
+	if http.DefaultClient.Transport != nil {
+		t.Errorf("Install() modified http.DefaultClient")
+	}
+	restore()
+	if requests.DefaultClient.Client != http.DefaultClient {
+		t.Errorf("restore() did not restore the client")
+	}
+}
+
+func TestTransportBody(t *testing.T) {
+	transport := New()
+	stub := transport.On("POST", "http://example.com").JSON(map[string]int{"a": 1})
+
+	// the body is read through GetBody, req is left as it was.
+	req, _ := http.NewRequest("POST", "http://example.com", strings.NewReader(`{"a":1}`))
+	body := req.Body
+	if _, err := transport.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() err = %v", err)
+	}
+	if req.Body != body {
+		t.Errorf("RoundTrip() replaced req.Body")
+	}
+
+	// stubs may be configured while requests are sent.
+	client := requests.NewClient(requests.WithTransport(transport))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client.Post("http://example.com", requests.Json{"a": 1})
+		}()
+		go func(i int) {
+			defer wg.Done()
+			stub.Reply(200, strconv.Itoa(i)).Delay(0).Times(0)
+		}(i)
+	}
+	wg.Wait()
+	if stub.Calls() != 11 {
+		t.Errorf("Calls() = %v, want 11", stub.Calls())
+	}
+}
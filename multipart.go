@@ -0,0 +1,175 @@
+package requests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// multipartBody is a multipart/form-data body laid out ahead of time: the part
+// headers and form fields are encoded once, the file contents are streamed
+// from their source every time the body is opened.
+type multipartBody struct {
+	contentType string
+	heads       [][]byte // heads[i] precedes files[i]
+	tail        []byte   // form fields and the closing boundary
+	files       []*file
+}
+
+func newMultipartBody(files []*file, form Form) (*multipartBody, error) {
+	buffer := &bytes.Buffer{}
+	multipartWriter := multipart.NewWriter(buffer)
+	body := &multipartBody{contentType: multipartWriter.FormDataContentType(), files: files}
+	for _, file := range files {
+		if _, err := multipartWriter.CreateFormFile(file.field, file.name); err != nil {
+			return nil, errors.Wrap(ErrInvalidFile, fmt.Sprintf("field: %s, name: %s, CreateFormFile err: %v", file.field, file.name, err))
+		}
+		body.heads = append(body.heads, append([]byte(nil), buffer.Bytes()...))
+		buffer.Reset()
+	}
+	for k, v := range form {
+		if err := multipartWriter.WriteField(k, v); err != nil {
+			return nil, errors.Wrap(ErrInvalidForm, fmt.Sprintf("Key: %s, Value: %s, WriteField err: %v", k, v, err))
+		}
+	}
+	if err := multipartWriter.Close(); err != nil {
+		return nil, err
+	}
+	body.tail = buffer.Bytes()
+	return body, nil
+}
+
+// size returns the encoded length of the body, or -1 if the size of a file is unknown.
+func (b *multipartBody) size() int64 {
+	n := int64(len(b.tail))
+	for i, file := range b.files {
+		if file.size < 0 {
+			return -1
+		}
+		n += int64(len(b.heads[i])) + file.size
+	}
+	return n
+}
+
+// replayable reports whether the body can be opened more than once.
+func (b *multipartBody) replayable() bool {
+	for _, file := range b.files {
+		if file.reader != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *multipartBody) open() (io.ReadCloser, error) {
+	readers := make([]io.Reader, 0, 2*len(b.files)+1)
+	closers := make(multiCloser, 0, len(b.files))
+	for i, file := range b.files {
+		content := file.open()
+		readers = append(readers, bytes.NewReader(b.heads[i]), content)
+		closers = append(closers, content)
+	}
+	readers = append(readers, bytes.NewReader(b.tail))
+	return &readCloser{Reader: io.MultiReader(readers...), Closer: closers}, nil
+}
+
+// gzipBody compresses the bodies returned by open while they are read. The compression starts
+// on the first Read, so a body which never reaches the transport holds no goroutine.
+func gzipBody(open func() (io.ReadCloser, error)) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		src, err := open()
+		if err != nil {
+			return nil, err
+		}
+		return &lazyGzip{src: src}, nil
+	}
+}
+
+// lazyGzip compresses src through a pipe started on the first Read. The transport may close
+// the body while it is being read, mu guards the pipe.
+type lazyGzip struct {
+	mu     sync.Mutex
+	src    io.ReadCloser
+	pr     *io.PipeReader
+	closed bool
+}
+
+func (g *lazyGzip) Read(p []byte) (int, error) {
+	g.mu.Lock()
+	if g.pr == nil {
+		if g.closed {
+			g.mu.Unlock()
+			return 0, io.ErrClosedPipe
+		}
+		pr, pw := io.Pipe()
+		go func(src io.ReadCloser) {
+			gw := gzip.NewWriter(pw)
+			_, err := io.Copy(gw, src)
+			if err == nil {
+				err = gw.Close()
+			}
+			_ = src.Close()
+			_ = pw.CloseWithError(err)
+		}(g.src)
+		g.pr = pr
+	}
+	pr := g.pr
+	g.mu.Unlock()
+	return pr.Read(p)
+}
+
+func (g *lazyGzip) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return nil
+	}
+	g.closed = true
+	if g.pr == nil {
+		return g.src.Close()
+	}
+	// the goroutine stops writing and closes src.
+	return g.pr.Close()
+}
+
+// lazyFile opens the file at path on the first Read.
+type lazyFile struct {
+	path string
+	f    *os.File
+}
+
+func (l *lazyFile) Read(p []byte) (int, error) {
+	if l.f == nil {
+		f, err := os.Open(l.path)
+		if err != nil {
+			return 0, errors.Wrap(ErrInvalidFile, fmt.Sprintf("open file err: %v", err))
+		}
+		l.f = f
+	}
+	return l.f.Read(p)
+}
+
+func (l *lazyFile) Close() error {
+	if l.f == nil {
+		return nil
+	}
+	return l.f.Close()
+}
+
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	var first error
+	for _, c := range m {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,146 @@
+package requests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// multipartEchoHandler replies with the request content length, the transfer encoding and the uploaded files and fields.
+func multipartEchoHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.Body = ioutil.NopCloser(gr)
+	}
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	var parts []string
+	for field, headers := range r.MultipartForm.File {
+		f, _ := headers[0].Open()
+		content, _ := ioutil.ReadAll(f)
+		f.Close()
+		parts = append(parts, field+"="+headers[0].Filename+":"+string(content))
+	}
+	for field, values := range r.MultipartForm.Value {
+		parts = append(parts, field+"="+values[0])
+	}
+	w.Header().Set("X-Content-Length", strconv.FormatInt(r.ContentLength, 10))
+	w.Header().Set("X-Transfer-Encoding", strings.Join(r.TransferEncoding, ","))
+	w.Write([]byte(strings.Join(parts, "&")))
+}
+
+func TestMultipartStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(multipartEchoHandler))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "go-requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.txt")
+	if err := ioutil.WriteFile(path, []byte("from disk"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		opts        []ReqOption
+		want        string
+		wantChunked bool
+	}{
+		{name: "path", opts: []ReqOption{FileWithPath("f", path)}, want: "f=a.txt:from disk"},
+		{name: "content", opts: []ReqOption{FileWithContent("f", "b.txt", []byte("hi"))}, want: "f=b.txt:hi"},
+		{name: "form", opts: []ReqOption{FileWithContent("f", "b.txt", []byte("hi")), Form{"k": "v"}}, want: "f=b.txt:hi&k=v"},
+		{name: "reader", opts: []ReqOption{FileWithReader("f", "c.txt", strings.NewReader("piped"))}, want: "f=c.txt:piped", wantChunked: true},
+		{name: "gzip", opts: []ReqOption{Gzip{}, FileWithPath("f", path)}, want: "f=a.txt:from disk", wantChunked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := Post(srv.URL, tt.opts...)
+			if err != nil {
+				t.Fatalf("Post() err = %v", err)
+			}
+			if got := resp.Text(); got != tt.want {
+				t.Errorf("Post() got = %v, want %v", got, tt.want)
+			}
+			chunked := resp.Header.Get("X-Transfer-Encoding") == "chunked"
+			if chunked != tt.wantChunked {
+				t.Errorf("Post() chunked = %v, want %v", chunked, tt.wantChunked)
+			}
+			if !chunked && resp.Header.Get("X-Content-Length") == "-1" {
+				t.Errorf("Post() Content-Length is unknown")
+			}
+		})
+	}
+}
+
+func TestGzipBodyNotSent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.txt")
+	if err := ioutil.WriteFile(path, []byte("from disk"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// a middleware answering from a cache never sends the body.
+	client := NewClient()
+	client.Use(func(next Handler) Handler {
+		return func(req *Request) (*Response, error) {
+			return &Response{Response: &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}}, nil
+		}
+	})
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		if _, err := client.Post(testUrl+"/post", FileWithPath("f", path), Gzip{}); err != nil {
+			t.Fatalf("Post() err = %v", err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if after := runtime.NumGoroutine(); after > before+2 {
+		t.Errorf("goroutines = %v after 20 requests, want about %v", after, before)
+	}
+}
+
+func TestMultipartBodySize(t *testing.T) {
+	body, err := newMultipartBody([]*file{
+		FileWithContent("a", "a.txt", []byte("hello")),
+		FileWithContent("b", "b.txt", bytes.Repeat([]byte("x"), 4096)),
+	}, Form{"k": "v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		rc, _ := body.open()
+		data, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		if int64(len(data)) != body.size() {
+			t.Errorf("size() = %v, read %v bytes", body.size(), len(data))
+		}
+	}
+}
+
+func TestFileWithPathMissing(t *testing.T) {
+	if _, err := Post(testUrl+"/upload", FileWithPath("f", "./not-exists.txt")); err == nil {
+		t.Errorf("FileWithPath() err = nil, want ErrInvalidFile")
+	}
+}
@@ -0,0 +1,145 @@
+package requests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// tokenExpiryDelta is how long before its expiry a token is refreshed.
+const tokenExpiryDelta = 10 * time.Second
+
+// OAuth2 configures the OAuth2 token endpoint used by WithOAuth2. Tokens are fetched
+// with the client credentials grant, or the refresh token grant if RefreshToken is set.
+type OAuth2 struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	RefreshToken string
+}
+
+// WithOAuth2 authenticates every request with a bearer token fetched from config.TokenURL.
+// The token is cached until shortly before it expires, concurrent requests share a single
+// fetch, and a request rejected with 401 is sent once more with a fresh token. Requests
+// setting their own Authorization header, e.g. with Bearer or BasicAuth, are left alone.
+func WithOAuth2(config OAuth2) ClientOption {
+	return func(client *Client) {
+		source := &tokenSource{config: config, client: &Client{Client: client.Client}}
+		client.options = append(client.options, source)
+	}
+}
+
+type oauth2Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	expiry       time.Time
+}
+
+func (t *oauth2Token) valid() bool {
+	return t != nil && t.AccessToken != "" && (t.expiry.IsZero() || time.Now().Add(tokenExpiryDelta).Before(t.expiry))
+}
+
+// tokenSource caches the token of an OAuth2 config, mu is held while a token is fetched.
+type tokenSource struct {
+	config OAuth2
+	client *Client
+
+	mu    sync.Mutex
+	token *oauth2Token
+}
+
+func (s *tokenSource) Do(req *Request) error {
+	req.auth = s
+	return nil
+}
+
+// authorize leaves alone an Authorization header set by the call, e.g. with Bearer or BasicAuth.
+func (s *tokenSource) authorize(req *Request) error {
+	if s.overridden(req) {
+		return nil
+	}
+	token, err := s.accessToken(req.Context())
+	if err != nil {
+		return err
+	}
+	req.authorization = "Bearer " + token
+	req.Header.Set("Authorization", req.authorization)
+	return nil
+}
+
+// overridden reports whether the Authorization header of req was not set by the token source.
+func (s *tokenSource) overridden(req *Request) bool {
+	current := req.Header.Get("Authorization")
+	return current != "" && current != req.authorization
+}
+
+func (s *tokenSource) reauthorize(req *Request, resp *Response) bool {
+	if s.overridden(req) {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != nil && req.Header.Get("Authorization") == "Bearer "+s.token.AccessToken {
+		s.token.AccessToken = ""
+	}
+	return true
+}
+
+func (s *tokenSource) accessToken(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token.valid() {
+		return s.token.AccessToken, nil
+	}
+	token, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	return token.AccessToken, nil
+}
+
+func (s *tokenSource) fetch(ctx context.Context) (*oauth2Token, error) {
+	form := Form{"grant_type": "client_credentials"}
+	refreshToken := s.config.RefreshToken
+	if s.token != nil && s.token.RefreshToken != "" {
+		refreshToken = s.token.RefreshToken
+	}
+	if refreshToken != "" {
+		form = Form{"grant_type": "refresh_token", "refresh_token": refreshToken}
+	}
+	if len(s.config.Scopes) > 0 {
+		form["scope"] = strings.Join(s.config.Scopes, " ")
+	}
+	resp, err := s.client.Post(s.config.TokenURL, form, Ctx{ctx},
+		BasicAuth{Username: s.config.ClientID, Password: s.config.ClientSecret},
+		Header{"Accept": "application/json"})
+	if err != nil {
+		return nil, errors.Wrap(ErrTokenFetch, err.Error())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrap(ErrTokenFetch, fmt.Sprintf("status: %s, body: %s", resp.Status, resp.Text()))
+	}
+	token := &oauth2Token{}
+	if err = resp.Json(token); err != nil {
+		return nil, errors.Wrap(ErrTokenFetch, err.Error())
+	}
+	if token.AccessToken == "" {
+		return nil, errors.Wrap(ErrTokenFetch, "access_token is empty")
+	}
+	if token.ExpiresIn > 0 {
+		token.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
@@ -0,0 +1,131 @@
+package requests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// oauth2Servers returns a token server issuing "token-N" and an api server accepting only the latest token.
+func oauth2Servers(t *testing.T, fetches *int32) (tokenSrv, apiSrv *httptest.Server) {
+	var latest atomic.Value
+	latest.Store("")
+	tokenSrv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "id" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = r.ParseForm()
+		if r.PostForm.Get("scope") != "read write" {
+			t.Errorf("scope = %v", r.PostForm.Get("scope"))
+		}
+		token := fmt.Sprintf("token-%d", atomic.AddInt32(fetches, 1))
+		latest.Store(token)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"%s","token_type":"bearer","expires_in":3600,"grant":"%s"}`, token, r.PostForm.Get("grant_type"))
+	}))
+	apiSrv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+latest.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	return tokenSrv, apiSrv
+}
+
+func TestOAuth2(t *testing.T) {
+	var fetches int32
+	tokenSrv, apiSrv := oauth2Servers(t, &fetches)
+	defer tokenSrv.Close()
+	defer apiSrv.Close()
+
+	client := NewClient(WithOAuth2(OAuth2{TokenURL: tokenSrv.URL, ClientID: "id", ClientSecret: "secret", Scopes: []string{"read", "write"}}))
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(apiSrv.URL)
+			if err != nil || resp.Text() != "Bearer token-1" {
+				t.Errorf("Get() got = %v, %v", resp, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if fetches != 1 {
+		t.Errorf("fetches = %v, want 1", fetches)
+	}
+
+	// the token is revoked: the 401 triggers a single refetch and the request is sent again.
+	if _, err := Post(tokenSrv.URL, BasicAuth{Username: "id", Password: "secret"}, Form{"scope": "read write"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Post(apiSrv.URL, Json{"a": 1})
+	if err != nil {
+		t.Fatalf("Post() err = %v", err)
+	}
+	if got := resp.Text(); got != "Bearer token-3" {
+		t.Errorf("Post() got = %v %v, want Bearer token-3", resp.StatusCode, got)
+	}
+}
+
+func TestOAuth2FetchError(t *testing.T) {
+	var fetches int32
+	tokenSrv, apiSrv := oauth2Servers(t, &fetches)
+	defer tokenSrv.Close()
+	defer apiSrv.Close()
+
+	client := NewClient(WithOAuth2(OAuth2{TokenURL: tokenSrv.URL, ClientID: "id", ClientSecret: "wrong"}))
+	if _, err := client.Get(apiSrv.URL); err == nil {
+		t.Errorf("Get() err = nil, want ErrTokenFetch")
+	}
+}
+
+func TestOAuth2RefreshToken(t *testing.T) {
+	var grants []string
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		grants = append(grants, r.PostForm.Get("grant_type")+":"+r.PostForm.Get("refresh_token"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":3600,"refresh_token":"r%d"}`, len(grants), len(grants))
+	}))
+	defer tokenSrv.Close()
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer apiSrv.Close()
+
+	client := NewClient(WithOAuth2(OAuth2{TokenURL: tokenSrv.URL, ClientID: "id", RefreshToken: "r0"}))
+	resp, err := client.Get(apiSrv.URL)
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	if got := resp.Text(); got != "Bearer token-2" {
+		t.Errorf("Get() got = %v, want Bearer token-2", got)
+	}
+	if want := []string{"refresh_token:r0", "refresh_token:r1"}; !reflect.DeepEqual(grants, want) {
+		t.Errorf("grants = %v, want %v", grants, want)
+	}
+
+	// a per-call Authorization header wins over the token.
+	resp, err = client.Get(apiSrv.URL, Bearer("call"))
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	if got := resp.Text(); got != "Bearer call" {
+		t.Errorf("Get() got = %v, want Bearer call", got)
+	}
+	if len(grants) != 2 {
+		t.Errorf("grants = %v, want no fetch for an overridden request", grants)
+	}
+}
@@ -20,6 +20,36 @@ func WithTimeout(timeout time.Duration) ClientOption {
 	return func(client *Client) { client.Timeout = timeout }
 }
 
+// WithConnectTimeout limits the time to establish a connection, including the DNS lookup.
+func WithConnectTimeout(timeout time.Duration) ClientOption {
+	return func(client *Client) { client.phases.connect = timeout }
+}
+
+// WithTLSHandshakeTimeout limits the time of the TLS handshake.
+func WithTLSHandshakeTimeout(timeout time.Duration) ClientOption {
+	return func(client *Client) { client.phases.tlsHandshake = timeout }
+}
+
+// WithResponseHeaderTimeout limits the time between writing the request and the first response byte.
+func WithResponseHeaderTimeout(timeout time.Duration) ClientOption {
+	return func(client *Client) { client.phases.responseHeader = timeout }
+}
+
+// WithReadTimeout limits the time without receiving any response body byte, it is reset by every read.
+func WithReadTimeout(timeout time.Duration) ClientOption {
+	return func(client *Client) { client.phases.read = timeout }
+}
+
+// WithTrace records the Timings of every request in Response.Timings.
+func WithTrace() ClientOption {
+	return func(client *Client) { client.trace = true }
+}
+
+// WithMiddleware wraps every attempt of a request with middlewares, see Client.Use.
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(client *Client) { client.Use(middlewares...) }
+}
+
 func WithTransport(transport http.RoundTripper) ClientOption {
 	return func(client *Client) { client.Transport = transport }
 }
@@ -32,6 +62,37 @@ func WithJar(jar http.CookieJar) ClientOption {
 	return func(client *Client) { client.Jar = jar }
 }
 
+// WithBaseURL resolves relative request urls against baseURL,
+// e.g. "users/1" or "/users/1" against "https://example.com/api" is "https://example.com/api/users/1".
+func WithBaseURL(baseURL string) ClientOption {
+	return func(client *Client) { client.baseURL = baseURL }
+}
+
+// WithDefaultOptions applies opts to every request before the options of the call, so that the latter win.
+func WithDefaultOptions(opts ...ReqOption) ClientOption {
+	return func(client *Client) { client.options = append(client.options, opts...) }
+}
+
+// WithAuth authenticates every request with auth, e.g. BasicAuth, Bearer, APIKey or a *DigestAuth.
+func WithAuth(auth ReqOption) ClientOption {
+	return WithDefaultOptions(auth)
+}
+
+// WithCodec encodes Json bodies and decodes Response.Json with codec instead of encoding/json.
+func WithCodec(codec Codec) ClientOption {
+	return func(client *Client) { client.codec = codec }
+}
+
+// WithRaiseForStatus makes requests return an *HTTPError along with responses whose status code is not 2xx.
+func WithRaiseForStatus() ClientOption {
+	return func(client *Client) { client.raiseForStatus = true }
+}
+
+// WithRetry retries requests according to retry unless a request sets its own Retry.
+func WithRetry(retry Retry) ClientOption {
+	return func(client *Client) { client.retry = &retry }
+}
+
 type ReqOption interface {
 	Do(req *Request) error
 }
@@ -51,6 +112,22 @@ func (p Params) Do(req *Request) error {
 	if len(p) == 0 {
 		return nil
 	}
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if req.defaults {
+			if req.defaultParams == nil {
+				req.defaultParams = map[string]bool{}
+			}
+			req.defaultParams[key] = true
+		} else if req.defaultParams[key] {
+			// the parameters of the call replace the ones of WithDefaultOptions.
+			req.removeParam(key)
+			delete(req.defaultParams, key)
+		}
+	}
 	if req.URL.RawQuery != "" {
 		req.URL.RawQuery += "&"
 	}
@@ -65,7 +142,7 @@ func (p Params) Do(req *Request) error {
 type Json map[string]interface{}
 
 func (j Json) Do(req *Request) error {
-	if req.files != nil || req.form != nil {
+	if req.files != nil || req.form != nil || req.hasBody() {
 		return ErrInvalidBodyType
 	}
 	if req.json == nil {
@@ -80,17 +157,130 @@ func (j Json) Do(req *Request) error {
 type Jsons []Json
 
 func (j Jsons) Do(req *Request) error {
-	if req.files != nil || req.form != nil {
+	if req.files != nil || req.form != nil || req.hasBody() {
 		return ErrInvalidBodyType
 	}
 	req.jsons = append(req.jsons, j...)
 	return nil
 }
 
+type jsonBody struct {
+	value interface{}
+}
+
+// JsonBody sends v encoded by the Codec as a json body, v may be any value, such as a struct or
+// a slice. A json.RawMessage or []byte is sent as it is.
+func JsonBody(v interface{}) ReqOption {
+	return &jsonBody{value: v}
+}
+
+func (j *jsonBody) Do(req *Request) error {
+	if req.hasAnyBody() {
+		return ErrInvalidBodyType
+	}
+	req.jsonBody = j
+	return nil
+}
+
+type xmlBody struct {
+	value interface{}
+}
+
+// XmlBody sends v encoded by encoding/xml as an application/xml body, a []byte is sent as it is.
+func XmlBody(v interface{}) ReqOption {
+	return &xmlBody{value: v}
+}
+
+func (x *xmlBody) Do(req *Request) error {
+	if req.hasAnyBody() {
+		return ErrInvalidBodyType
+	}
+	req.xmlBody = x
+	return nil
+}
+
+type rawBody struct {
+	data        []byte
+	reader      io.Reader
+	offset      int64 // start of the body in a seekable reader
+	size        int64
+	contentType string
+}
+
+// Body sends body as it is with the given Content-Type, body is a []byte, a string or an io.Reader.
+// Seekable readers set the Content-Length, and those also implementing io.ReaderAt, such as
+// *bytes.Reader, *strings.Reader and *os.File, can be sent again on redirects and retries.
+func Body(body interface{}, contentType string) ReqOption {
+	raw := &rawBody{contentType: contentType, size: -1}
+	switch v := body.(type) {
+	case []byte:
+		raw.data = v
+	case string:
+		raw.data = []byte(v)
+	case *bytes.Buffer:
+		raw.data = v.Bytes()
+	case io.Reader:
+		raw.reader = v
+	}
+	return raw
+}
+
+func (b *rawBody) Do(req *Request) error {
+	if req.hasAnyBody() || (b.data == nil && b.reader == nil) {
+		return ErrInvalidBodyType
+	}
+	if b.data != nil {
+		b.size = int64(len(b.data))
+	} else if seeker, ok := b.reader.(io.Seeker); ok {
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return errors.Wrap(ErrInvalidBodyType, err.Error())
+		}
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return errors.Wrap(ErrInvalidBodyType, err.Error())
+		}
+		if _, err = seeker.Seek(offset, io.SeekStart); err != nil {
+			return errors.Wrap(ErrInvalidBodyType, err.Error())
+		}
+		b.offset, b.size = offset, end-offset
+	}
+	req.rawBody = b
+	return nil
+}
+
+// replayable reports whether the body can be opened more than once.
+func (b *rawBody) replayable() bool {
+	_, readerAt := b.reader.(io.ReaderAt)
+	return b.data != nil || readerAt && b.size >= 0
+}
+
+func (b *rawBody) open() (io.ReadCloser, error) {
+	if b.data != nil {
+		return ioutil.NopCloser(bytes.NewReader(b.data)), nil
+	}
+	if b.replayable() {
+		// each body reads its own section, so a copy read through GetBody does not drain the one
+		// being sent. The transport closes the body after each send, the reader is closed by close.
+		return ioutil.NopCloser(io.NewSectionReader(b.reader.(io.ReaderAt), b.offset, b.size)), nil
+	}
+	if rc, ok := b.reader.(io.ReadCloser); ok {
+		return rc, nil
+	}
+	return ioutil.NopCloser(b.reader), nil
+}
+
+// close closes a replayable reader once the request is finished.
+func (b *rawBody) close() {
+	if closer, ok := b.reader.(io.Closer); ok && b.replayable() {
+		_ = closer.Close()
+	}
+}
+
 type Form map[string]string
 
 func (f Form) Do(req *Request) error {
-	if req.json != nil || req.jsons != nil {
+	if req.json != nil || req.jsons != nil || req.hasBody() {
 		return ErrInvalidBodyType
 	}
 	if req.form == nil {
@@ -105,6 +295,13 @@ func (f Form) Do(req *Request) error {
 type Cookies map[string]string
 
 func (c Cookies) Do(req *Request) error {
+	existing := req.Cookies()
+	req.Header.Del("Cookie")
+	for _, cookie := range existing {
+		if _, ok := c[cookie.Name]; !ok {
+			req.AddCookie(cookie)
+		}
+	}
 	for name, value := range c {
 		req.AddCookie(&http.Cookie{Name: name, Value: value})
 	}
@@ -113,9 +310,11 @@ func (c Cookies) Do(req *Request) error {
 
 type file struct {
 	field    string // file field
-	content  io.ReadCloser
 	name     string // file name
 	filePath string
+	content  []byte
+	reader   io.Reader
+	size     int64 // -1 if unknown
 }
 
 func FileWithPath(field string, path string) *file {
@@ -123,28 +322,55 @@ func FileWithPath(field string, path string) *file {
 }
 
 func FileWithContent(field, fileName string, content []byte) *file {
-	return &file{field: field, name: fileName, content: ioutil.NopCloser(bytes.NewReader(content))}
+	if content == nil {
+		content = []byte{}
+	}
+	return &file{field: field, name: fileName, content: content, size: int64(len(content))}
+}
+
+// FileWithReader uploads the content read from r. The size is unknown, so the body is sent
+// with chunked encoding, and the request can not be retried.
+func FileWithReader(field, fileName string, r io.Reader) *file {
+	return &file{field: field, name: fileName, reader: r, size: -1}
 }
 
 func (f file) Do(req *Request) error {
+	if req.json != nil || req.jsons != nil || req.hasBody() {
+		return ErrInvalidBodyType
+	}
 	if f.field == "" {
 		return errors.Wrap(ErrInvalidFile, "field is nil")
 	} else if f.name == "" {
 		return errors.Wrap(ErrInvalidFile, "fileName is nil")
 	}
-	if f.content == nil && f.filePath == "" {
+	if f.content == nil && f.reader == nil && f.filePath == "" {
 		return errors.Wrap(ErrInvalidFile, "content is nil, path is nil")
-	} else if f.content == nil {
-		fc, err := os.Open(f.filePath)
+	} else if f.filePath != "" {
+		info, err := os.Stat(f.filePath)
 		if err != nil {
 			return errors.Wrap(ErrInvalidFile, fmt.Sprintf("open file err: %v", err))
 		}
-		f.content = fc
+		if info.IsDir() {
+			return errors.Wrap(ErrInvalidFile, fmt.Sprintf("open file err: %s is a directory", f.filePath))
+		}
+		f.size = info.Size()
 	}
 	req.files = append(req.files, &f)
 	return nil
 }
 
+// open returns the file content, files on disk are opened on the first Read.
+func (f *file) open() io.ReadCloser {
+	switch {
+	case f.filePath != "":
+		return &lazyFile{path: f.filePath}
+	case f.reader != nil:
+		return ioutil.NopCloser(f.reader)
+	default:
+		return ioutil.NopCloser(bytes.NewReader(f.content))
+	}
+}
+
 type Ctx struct {
 	context.Context
 }
@@ -154,15 +380,47 @@ func (c Ctx) Do(req *Request) error {
 	return nil
 }
 
+// Timeout limits the time of the whole request, including retries and reading the response body.
+// It overrides a Timeout applied before, e.g. by WithDefaultOptions.
 type Timeout time.Duration
 
 func (t Timeout) Do(req *Request) error {
 	if time.Duration(t) == 0 {
 		return nil
 	}
-	ctx, _ := context.WithTimeout(req.Context(), time.Duration(t))
-	// todo call cancel
-	req.Request = req.WithContext(ctx)
+	req.timeout = time.Duration(t)
+	return nil
+}
+
+// ConnectTimeout overrides WithConnectTimeout for one request.
+type ConnectTimeout time.Duration
+
+func (t ConnectTimeout) Do(req *Request) error {
+	req.phases.connect = time.Duration(t)
+	return nil
+}
+
+// TLSHandshakeTimeout overrides WithTLSHandshakeTimeout for one request.
+type TLSHandshakeTimeout time.Duration
+
+func (t TLSHandshakeTimeout) Do(req *Request) error {
+	req.phases.tlsHandshake = time.Duration(t)
+	return nil
+}
+
+// ResponseHeaderTimeout overrides WithResponseHeaderTimeout for one request.
+type ResponseHeaderTimeout time.Duration
+
+func (t ResponseHeaderTimeout) Do(req *Request) error {
+	req.phases.responseHeader = time.Duration(t)
+	return nil
+}
+
+// ReadTimeout overrides WithReadTimeout for one request.
+type ReadTimeout time.Duration
+
+func (t ReadTimeout) Do(req *Request) error {
+	req.phases.read = time.Duration(t)
 	return nil
 }
 
@@ -172,3 +430,22 @@ func (Gzip) Do(req *Request) error {
 	req.gzip = true
 	return nil
 }
+
+// Stream leaves the response body open to be read incrementally from Response.Body,
+// Text, Bytes and Json read it on demand. The caller must call Response.Close.
+type Stream struct{}
+
+func (Stream) Do(req *Request) error {
+	req.stream = true
+	return nil
+}
+
+// RaiseForStatus overrides WithRaiseForStatus for one request: when true, a response whose
+// status code is not 2xx is returned along with an *HTTPError.
+type RaiseForStatus bool
+
+func (r RaiseForStatus) Do(req *Request) error {
+	raise := bool(r)
+	req.raiseForStatus = &raise
+	return nil
+}
@@ -1,9 +1,21 @@
 package requests
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -69,10 +81,10 @@ func TestHeaders(t *testing.T) {
 		args args
 		want map[string][]string
 	}{
-		{args: args{}, want: map[string][]string{"Accept-Encoding": {"gzip"}, "User-Agent": {userAgent}}},
-		{args: args{headers: []ReqOption{Header{"a": "1"}}}, want: map[string][]string{"Accept-Encoding": {"gzip"}, "User-Agent": {userAgent}, "A": {"1"}}},
-		{args: args{headers: []ReqOption{Header{"a": "1", "b": "2"}}}, want: map[string][]string{"Accept-Encoding": {"gzip"}, "User-Agent": {userAgent}, "A": {"1"}, "B": {"2"}}},
-		{args: args{headers: []ReqOption{Header{"a": "1"}, Header{"b": "2"}}}, want: map[string][]string{"Accept-Encoding": {"gzip"}, "User-Agent": {userAgent}, "A": {"1"}, "B": {"2"}}},
+		{args: args{}, want: map[string][]string{"Accept-Encoding": {"gzip, deflate, br, zstd"}, "User-Agent": {userAgent}}},
+		{args: args{headers: []ReqOption{Header{"a": "1"}}}, want: map[string][]string{"Accept-Encoding": {"gzip, deflate, br, zstd"}, "User-Agent": {userAgent}, "A": {"1"}}},
+		{args: args{headers: []ReqOption{Header{"a": "1", "b": "2"}}}, want: map[string][]string{"Accept-Encoding": {"gzip, deflate, br, zstd"}, "User-Agent": {userAgent}, "A": {"1"}, "B": {"2"}}},
+		{args: args{headers: []ReqOption{Header{"a": "1"}, Header{"b": "2"}}}, want: map[string][]string{"Accept-Encoding": {"gzip, deflate, br, zstd"}, "User-Agent": {userAgent}, "A": {"1"}, "B": {"2"}}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -113,6 +125,36 @@ func TestParams(t *testing.T) {
 	}
 }
 
+func TestParamsRawQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		defaults []ReqOption
+		opts     []ReqOption
+		want     string
+	}{
+		{name: "append", url: "http://a.com/?z=1&a=2&a=3", opts: []ReqOption{Params{"a": "4"}}, want: "z=1&a=2&a=3&a=4"},
+		{name: "semicolon kept", url: "http://a.com/?x=1;2", opts: []ReqOption{Params{"b": "1"}}, want: "x=1;2&b=1"},
+		{name: "default replaced", url: "http://a.com/?z=1", defaults: []ReqOption{Params{"a": "d", "b": "d"}}, opts: []ReqOption{Params{"a": "c"}}, want: "z=1&b=d&a=c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := NewRequest(GET, tt.url)
+			req.defaults = true
+			for _, opt := range tt.defaults {
+				_ = opt.Do(req)
+			}
+			req.defaults = false
+			for _, opt := range tt.opts {
+				_ = opt.Do(req)
+			}
+			if got := req.URL.RawQuery; got != tt.want {
+				t.Errorf("RawQuery got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestJson(t *testing.T) {
 	url := testUrl + "/post"
 	type args struct {
@@ -260,3 +302,254 @@ func TestGzip(t *testing.T) {
 		})
 	}
 }
+
+func TestJsonBody(t *testing.T) {
+	url := testUrl + "/post"
+	type point struct {
+		Y int    `json:"y"`
+		X string `json:"x,omitempty"`
+	}
+	tests := []struct {
+		name    string
+		opts    []ReqOption
+		want    string
+		wantErr bool
+	}{
+		{name: "struct", opts: []ReqOption{JsonBody(point{Y: 1, X: "a"})}, want: `{"y":1,"x":"a"}`},
+		{name: "tags", opts: []ReqOption{JsonBody(&point{Y: 2})}, want: `{"y":2}`},
+		{name: "slice", opts: []ReqOption{JsonBody([]int{1, 2})}, want: `[1,2]`},
+		{name: "raw message", opts: []ReqOption{JsonBody(json.RawMessage(`{"b": 1,  "a": 2}`))}, want: `{"b": 1,  "a": 2}`},
+		{name: "bytes", opts: []ReqOption{JsonBody([]byte(`[true]`))}, want: `[true]`},
+		{name: "gzip", opts: []ReqOption{Gzip{}, JsonBody(point{Y: 3})}, want: `{"y":3}`},
+		{name: "form conflict", opts: []ReqOption{Form{"a": "1"}, JsonBody(point{})}, wantErr: true},
+		{name: "file conflict", opts: []ReqOption{JsonBody(point{}), FileWithContent("f", "f", nil)}, wantErr: true},
+		{name: "json conflict", opts: []ReqOption{JsonBody(point{}), Json{"a": 1}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := Post(url, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("JsonBody() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if err != ErrInvalidBodyType {
+					t.Errorf("JsonBody() err = %v, want %v", err, ErrInvalidBodyType)
+				}
+				return
+			}
+			if got := resp.Text(); got != tt.want {
+				t.Errorf("JsonBody() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXmlBody(t *testing.T) {
+	url := testUrl + "/post"
+	type item struct {
+		XMLName struct{} `xml:"item"`
+		Name    string   `xml:"name"`
+	}
+	tests := []struct {
+		name     string
+		opts     []ReqOption
+		want     string
+		wantName string
+		wantErr  bool
+	}{
+		{name: "struct", opts: []ReqOption{XmlBody(item{Name: "a"})}, want: xml.Header + `<item><name>a</name></item>`, wantName: "a"},
+		{name: "bytes", opts: []ReqOption{XmlBody([]byte(`<item><name>c</name></item>`))}, want: `<item><name>c</name></item>`, wantName: "c"},
+		{name: "gzip", opts: []ReqOption{Gzip{}, XmlBody(item{Name: "b"})}, want: xml.Header + `<item><name>b</name></item>`, wantName: "b"},
+		{name: "json conflict", opts: []ReqOption{Json{"a": 1}, XmlBody(item{})}, wantErr: true},
+		{name: "form conflict", opts: []ReqOption{XmlBody(item{}), Form{"a": "1"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := Post(url, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("XmlBody() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := resp.Text(); got != tt.want {
+				t.Errorf("XmlBody() got = %v, want %v", got, tt.want)
+			}
+			var got item
+			if err := resp.Xml(&got); err != nil || got.Name != tt.wantName {
+				t.Errorf("Xml() got = %v, err = %v, want %v", got.Name, err, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gr, _ := gzip.NewReader(r.Body)
+			r.Body = ioutil.NopCloser(gr)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Content-Length", fmt.Sprint(r.ContentLength))
+		w.Write([]byte(r.Header.Get("Content-Type") + "|" + string(body)))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		opts       []ReqOption
+		want       string
+		wantLength string
+		wantErr    bool
+	}{
+		{name: "bytes", opts: []ReqOption{Body([]byte{'a', 'b'}, "application/octet-stream")}, want: "application/octet-stream|ab", wantLength: "2"},
+		{name: "string", opts: []ReqOption{Body("a,b\n1,2\n", "text/csv")}, want: "text/csv|a,b\n1,2\n", wantLength: "8"},
+		{name: "bytes reader", opts: []ReqOption{Body(bytes.NewReader([]byte("abc")), "text/plain")}, want: "text/plain|abc", wantLength: "3"},
+		{name: "buffer", opts: []ReqOption{Body(bytes.NewBufferString("abcd"), "text/plain")}, want: "text/plain|abcd", wantLength: "4"},
+		{name: "reader", opts: []ReqOption{Body(ioutil.NopCloser(strings.NewReader("abc")), "text/plain")}, want: "text/plain|abc", wantLength: "-1"},
+		{name: "gzip bytes", opts: []ReqOption{Gzip{}, Body("abc", "text/plain")}, want: "text/plain|abc"},
+		{name: "gzip reader", opts: []ReqOption{Gzip{}, Body(strings.NewReader("abc"), "text/plain")}, want: "text/plain|abc", wantLength: "-1"},
+		{name: "invalid type", opts: []ReqOption{Body(1, "text/plain")}, wantErr: true},
+		{name: "conflict", opts: []ReqOption{Json{"a": 1}, Body("abc", "text/plain")}, wantErr: true},
+		{name: "conflict form", opts: []ReqOption{Body("abc", "text/plain"), Form{"a": "1"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := Post(srv.URL, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Body() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := resp.Text(); got != tt.want {
+				t.Errorf("Body() got = %q, want %q", got, tt.want)
+			}
+			if got := resp.Header.Get("X-Content-Length"); tt.wantLength != "" && got != tt.wantLength {
+				t.Errorf("Body() Content-Length = %v, want %v", got, tt.wantLength)
+			}
+		})
+	}
+}
+
+func TestBodyReplay(t *testing.T) {
+	srv, calls := flakyServer(1, http.StatusServiceUnavailable, nil)
+	defer srv.Close()
+	reader := strings.NewReader("xxabc")
+	reader.Seek(2, io.SeekStart)
+	resp, err := Post(srv.URL, Body(reader, "text/plain"), Retry{BaseDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Body() err = %v", err)
+	}
+	if got := resp.Text(); got != "abc" || *calls != 2 {
+		t.Errorf("Body() got = %v after %v calls, want abc after 2 calls", got, *calls)
+	}
+
+	// a file is sent again and closed once the request is finished.
+	file, err := ioutil.TempFile(t.TempDir(), "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("file")
+	file.Seek(0, io.SeekStart)
+	fileSrv, fileCalls := flakyServer(1, http.StatusServiceUnavailable, nil)
+	defer fileSrv.Close()
+	resp, err = Post(fileSrv.URL, Body(file, "text/plain"), Retry{BaseDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Body() file err = %v", err)
+	}
+	if got := resp.Text(); got != "file" || *fileCalls != 2 {
+		t.Errorf("Body() file got = %v after %v calls, want file after 2 calls", got, *fileCalls)
+	}
+	if _, err = file.Read(make([]byte, 1)); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Body() file read err = %v, want os.ErrClosed", err)
+	}
+
+	// the copies read by WithDebug and CurlHook leave the body being sent untouched.
+	var debug bytes.Buffer
+	client := NewClient(WithDebug(&debug))
+	client.AddHook(CurlHook{Logger: &recordLogger{}})
+	resp, err = client.Post(testUrl+"/post", Body(strings.NewReader("hello"), "text/plain"))
+	if err != nil || resp.Text() != "hello" || !strings.Contains(debug.String(), "\r\n\r\nhello") {
+		t.Errorf("Body() with WithDebug got = %v, err = %v, debug = %q", resp, err, debug.String())
+	}
+
+	// a 307 redirect sends the body again through GetBody.
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, testUrl+"/post", http.StatusTemporaryRedirect)
+	}))
+	defer redirect.Close()
+	resp, err = Post(redirect.URL, Body([]byte("moved"), "text/plain"))
+	if err != nil || resp.Text() != "moved" {
+		t.Errorf("Body() redirect got = %v, err = %v", resp.Text(), err)
+	}
+}
+
+func TestTimeoutErrors(t *testing.T) {
+	url := testUrl + "/timeout"
+	_, err := Get(url, Timeout(50*time.Millisecond))
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Timeout() err = %v, want ErrTimeout and context.DeadlineExceeded", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = Get(url, Ctx{ctx})
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Ctx() err = %v, want ErrTimeout and context.Canceled", err)
+	}
+
+	_, err = NewClient(WithTimeout(50 * time.Millisecond)).Get(url)
+	if !errors.Is(err, ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WithTimeout() err = %v, want ErrTimeout and context.DeadlineExceeded", err)
+	}
+
+	// the timeout of the call overrides the default one.
+	client := NewClient(WithDefaultOptions(Timeout(50 * time.Millisecond)))
+	if _, err = client.Get(url, Timeout(2*time.Second)); err != nil {
+		t.Errorf("Timeout() override err = %v", err)
+	}
+}
+
+func TestTimeoutStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("second"))
+	}))
+	defer srv.Close()
+
+	// the timer keeps running while the body is read.
+	resp, err := Get(srv.URL, Stream{}, Timeout(time.Second))
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	if got := resp.Text(); got != "firstsecond" {
+		t.Errorf("Text() got = %v, want firstsecond", got)
+	}
+
+	resp, err = Get(srv.URL, Stream{}, Timeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	defer resp.Close()
+	if _, err := ioutil.ReadAll(resp.Body); err == nil {
+		t.Errorf("ReadAll() err = nil, want deadline exceeded")
+	}
+}
+
+func TestTimeoutConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := Get(testUrl+"/timeout", Timeout(time.Duration(i+1)*time.Millisecond))
+			if !errors.Is(err, ErrTimeout) {
+				t.Errorf("Get() err = %v, want ErrTimeout", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,459 @@
+package requests
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidCurl will be throw out when a curl command line can not be parsed
+var ErrInvalidCurl = errors.New("go-requests: Invalid curl command")
+
+// CurlCommand is a curl command line parsed by ParseCurl:
+//
+//	cmd, err := requests.ParseCurl(`curl -X POST https://example.com -H 'X-Id: 1' -d a=1`)
+//	client := requests.NewClient(cmd.ClientOptions()...)
+//	resp, err := client.Request(cmd.Method, cmd.URL, cmd.Options...)
+type CurlCommand struct {
+	Method  string
+	URL     string
+	Options []ReqOption
+	// Insecure is set by -k, the certificate of the server is not verified.
+	Insecure bool
+}
+
+// ClientOptions returns the options of the client the command needs, WithInsecureSkipVerify for -k.
+func (c *CurlCommand) ClientOptions() []ClientOption {
+	if c.Insecure {
+		return []ClientOption{WithInsecureSkipVerify()}
+	}
+	return nil
+}
+
+// WithInsecureSkipVerify disables the verification of the server certificate, for tests only.
+func WithInsecureSkipVerify() ClientOption {
+	return func(client *Client) {
+		transport, ok := client.Transport.(*http.Transport)
+		if client.Transport == nil {
+			transport, ok = http.DefaultTransport.(*http.Transport)
+		}
+		if !ok {
+			return
+		}
+		transport = transport.Clone()
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+		client.Transport = transport
+	}
+}
+
+// curlFlags maps the long options understood by ParseCurl to their short name, or to themselves.
+var curlFlags = map[string]string{
+	"--request":        "-X",
+	"--header":         "-H",
+	"--data":           "-d",
+	"--data-ascii":     "-d",
+	"--data-binary":    "--data-binary",
+	"--data-raw":       "--data-raw",
+	"--data-urlencode": "--data-urlencode",
+	"--form":           "-F",
+	"--form-string":    "--form-string",
+	"--user":           "-u",
+	"--cookie":         "-b",
+	"--user-agent":     "-A",
+	"--referer":        "-e",
+	"--url":            "--url",
+	"--get":            "-G",
+	"--head":           "-I",
+	"--compressed":     "--compressed",
+	"--insecure":       "-k",
+	"--location":       "-L",
+	"--silent":         "-s",
+	"--show-error":     "-S",
+	"--verbose":        "-v",
+	"--include":        "-i",
+}
+
+// curlValueFlags are the short options taking a value.
+const curlValueFlags = "XHdFubAe"
+
+// ParseCurl parses a curl command line into the method, url and options of a request. It understands
+// -X, -H, -d, --data-raw, --data-binary, --data-urlencode, -F, --form-string, -u, -b, -A, -e, -G, -I,
+// and -k, ignores --compressed, -L, -s, -S, -v and -i, and fails on other options. Words are quoted
+// as in a POSIX shell, including the $'...' quoting of the "Copy as cURL" of browsers.
+func ParseCurl(command string) (*CurlCommand, error) {
+	args, err := splitShell(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, errors.Wrap(ErrInvalidCurl, "it does not start with curl")
+	}
+	cmd := &CurlCommand{}
+	header := Header{}
+	var data []string
+	var files []ReqOption
+	var form Form
+	var get, head bool
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if cmd.URL != "" {
+				return nil, errors.Wrapf(ErrInvalidCurl, "unexpected argument %s", arg)
+			}
+			cmd.URL = arg
+			continue
+		}
+		var flag, value string
+		hasValue := false
+		if strings.HasPrefix(arg, "--") {
+			flag = arg
+			if eq := strings.IndexByte(arg, '='); eq > 0 {
+				flag, value, hasValue = arg[:eq], arg[eq+1:], true
+			}
+			short, ok := curlFlags[flag]
+			if !ok {
+				return nil, errors.Wrapf(ErrInvalidCurl, "unsupported option %s", flag)
+			}
+			flag = short
+		} else {
+			// short options may be grouped, e.g. -sSL, or followed by their value, e.g. -XPOST.
+			for j := 1; j < len(arg); j++ {
+				flag = "-" + arg[j:j+1]
+				if strings.ContainsRune(curlValueFlags, rune(arg[j])) {
+					if j+1 < len(arg) {
+						value, hasValue = arg[j+1:], true
+					}
+					break
+				}
+				if j+1 < len(arg) {
+					if err := cmd.setFlag(flag, &get, &head); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		needsValue := strings.HasPrefix(flag, "--") && flag != "--compressed" || len(flag) == 2 && strings.ContainsRune(curlValueFlags, rune(flag[1]))
+		if needsValue && !hasValue {
+			if i+1 >= len(args) {
+				return nil, errors.Wrapf(ErrInvalidCurl, "option %s needs a value", arg)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch flag {
+		case "-X":
+			cmd.Method = strings.ToUpper(value)
+		case "-H":
+			colon := strings.IndexByte(value, ':')
+			if colon <= 0 {
+				return nil, errors.Wrapf(ErrInvalidCurl, "invalid header %q", value)
+			}
+			header[strings.TrimSpace(value[:colon])] = strings.TrimSpace(value[colon+1:])
+		case "-A":
+			header["User-Agent"] = value
+		case "-e":
+			header["Referer"] = value
+		case "-b":
+			if !strings.Contains(value, "=") {
+				return nil, errors.Wrapf(ErrInvalidCurl, "unsupported cookie file %s", value)
+			}
+			cookies := Cookies{}
+			for _, pair := range strings.Split(value, ";") {
+				if kv := strings.SplitN(strings.TrimSpace(pair), "=", 2); len(kv) == 2 {
+					cookies[kv[0]] = kv[1]
+				}
+			}
+			cmd.Options = append(cmd.Options, cookies)
+		case "-u":
+			kv := strings.SplitN(value, ":", 2)
+			auth := BasicAuth{Username: kv[0]}
+			if len(kv) == 2 {
+				auth.Password = kv[1]
+			}
+			cmd.Options = append(cmd.Options, auth)
+		case "-d", "--data-binary", "--data-raw":
+			if flag != "--data-raw" && strings.HasPrefix(value, "@") {
+				content, err := ioutil.ReadFile(value[1:])
+				if err != nil {
+					return nil, errors.Wrapf(ErrInvalidCurl, "%s: %v", arg, err)
+				}
+				value = string(content)
+				if flag == "-d" {
+					value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+				}
+			}
+			data = append(data, value)
+		case "--data-urlencode":
+			encoded, err := curlURLEncode(value)
+			if err != nil {
+				return nil, errors.Wrapf(ErrInvalidCurl, "%s: %v", arg, err)
+			}
+			data = append(data, encoded)
+		case "-F", "--form-string":
+			eq := strings.IndexByte(value, '=')
+			if eq <= 0 {
+				return nil, errors.Wrapf(ErrInvalidCurl, "invalid form part %q", value)
+			}
+			name, content := value[:eq], value[eq+1:]
+			if form == nil {
+				form = Form{}
+			}
+			switch {
+			case flag == "--form-string":
+				form[name] = content
+			case strings.HasPrefix(content, "@"):
+				params := strings.Split(content[1:], ";")
+				file := FileWithPath(name, params[0])
+				for _, param := range params[1:] {
+					if strings.HasPrefix(param, "filename=") {
+						file.name = strings.Trim(strings.TrimPrefix(param, "filename="), `"`)
+					}
+				}
+				files = append(files, file)
+			case strings.HasPrefix(content, "<"):
+				fileContent, err := ioutil.ReadFile(strings.Split(content[1:], ";")[0])
+				if err != nil {
+					return nil, errors.Wrapf(ErrInvalidCurl, "%s: %v", arg, err)
+				}
+				form[name] = string(fileContent)
+			default:
+				form[name] = strings.Split(content, ";")[0]
+			}
+		case "--url":
+			cmd.URL = value
+		default:
+			if err := cmd.setFlag(flag, &get, &head); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if cmd.URL == "" {
+		return nil, errors.Wrap(ErrInvalidCurl, "no url")
+	}
+
+	if len(header) > 0 {
+		cmd.Options = append(cmd.Options, header)
+	}
+	body := strings.Join(data, "&")
+	switch {
+	case form != nil:
+		if len(data) > 0 {
+			return nil, errors.Wrap(ErrInvalidCurl, "-d and -F can not be used together")
+		}
+		cmd.Options = append(cmd.Options, form, multipartForm{})
+		cmd.Options = append(cmd.Options, files...)
+		cmd.defaultMethod(POST)
+	case len(data) > 0 && get:
+		separator := "?"
+		if strings.Contains(cmd.URL, "?") {
+			separator = "&"
+		}
+		cmd.URL += separator + body
+	case len(data) > 0:
+		contentType := "application/x-www-form-urlencoded"
+		for key := range header {
+			if strings.EqualFold(key, "Content-Type") {
+				contentType = ""
+			}
+		}
+		cmd.Options = append(cmd.Options, Body(body, contentType))
+		cmd.defaultMethod(POST)
+	}
+	if head {
+		cmd.defaultMethod(HEAD)
+	}
+	cmd.defaultMethod(GET)
+	return cmd, nil
+}
+
+func (c *CurlCommand) defaultMethod(method string) {
+	if c.Method == "" {
+		c.Method = method
+	}
+}
+
+// setFlag applies an option without a value.
+func (c *CurlCommand) setFlag(flag string, get, head *bool) error {
+	switch flag {
+	case "-G":
+		*get = true
+	case "-I":
+		*head = true
+	case "-k":
+		c.Insecure = true
+	case "--compressed", "-L", "-s", "-S", "-v", "-i":
+		// responses are decoded and redirects followed by default.
+	default:
+		return errors.Wrapf(ErrInvalidCurl, "unsupported option %s", flag)
+	}
+	return nil
+}
+
+// curlURLEncode encodes a --data-urlencode value: content, =content, name=content, @file or name@file.
+func curlURLEncode(value string) (string, error) {
+	name, content := "", value
+	if eq := strings.IndexByte(value, '='); eq >= 0 {
+		name, content = value[:eq], value[eq+1:]
+	} else if at := strings.IndexByte(value, '@'); at >= 0 {
+		data, err := ioutil.ReadFile(value[at+1:])
+		if err != nil {
+			return "", err
+		}
+		name, content = value[:at], string(data)
+	}
+	if name == "" {
+		return url.QueryEscape(content), nil
+	}
+	return name + "=" + url.QueryEscape(content), nil
+}
+
+// multipartForm sends the Form as multipart/form-data even without files, like curl -F.
+type multipartForm struct{}
+
+func (multipartForm) Do(req *Request) error {
+	req.multipart = true
+	return nil
+}
+
+// splitShell splits a command line into words like a POSIX shell, handling quotes,
+// backslash escapes and line continuations.
+func splitShell(s string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 < len(s) {
+				i++
+				if s[i] != '\n' {
+					word.WriteByte(s[i])
+					inWord = true
+				}
+			}
+		case c == '$' && i+1 < len(s) && s[i+1] == '\'':
+			quoted, end, err := ansiCQuote(s, i)
+			if err != nil {
+				return nil, err
+			}
+			word.WriteString(quoted)
+			i = end
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.Wrap(ErrInvalidCurl, "unterminated quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, errors.Wrap(ErrInvalidCurl, "unterminated quote")
+			}
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+// ansiCQuote decodes the $'...' word starting at s[i], used by the "Copy as cURL" of browsers
+// when a value holds quotes or control characters, and returns it with the index of its
+// closing quote.
+func ansiCQuote(s string, i int) (string, int, error) {
+	var word strings.Builder
+	for i += 2; i < len(s); i++ {
+		c := s[i]
+		if c == '\'' {
+			return word.String(), i, nil
+		}
+		if c != '\\' || i+1 >= len(s) {
+			word.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = s[i]; c {
+		case 'a':
+			word.WriteByte('\a')
+		case 'b':
+			word.WriteByte('\b')
+		case 'e', 'E':
+			word.WriteByte(0x1b)
+		case 'f':
+			word.WriteByte('\f')
+		case 'n':
+			word.WriteByte('\n')
+		case 'r':
+			word.WriteByte('\r')
+		case 't':
+			word.WriteByte('\t')
+		case 'v':
+			word.WriteByte('\v')
+		case '\\', '\'', '"', '?':
+			word.WriteByte(c)
+		case 'x', 'u', 'U':
+			digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[c]
+			end := i + 1
+			for end < len(s) && end <= i+digits && strings.IndexByte("0123456789abcdefABCDEF", s[end]) >= 0 {
+				end++
+			}
+			if end == i+1 {
+				word.WriteByte('\\')
+				word.WriteByte(c)
+				continue
+			}
+			n, _ := strconv.ParseUint(s[i+1:end], 16, 32)
+			if c == 'x' {
+				word.WriteByte(byte(n))
+			} else {
+				word.WriteRune(rune(n))
+			}
+			i = end - 1
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			end := i
+			for end < len(s) && end < i+3 && s[end] >= '0' && s[end] <= '7' {
+				end++
+			}
+			n, _ := strconv.ParseUint(s[i:end], 8, 16)
+			word.WriteByte(byte(n))
+			i = end - 1
+		default:
+			// unknown escapes are kept as they are, as bash does.
+			word.WriteByte('\\')
+			word.WriteByte(c)
+		}
+	}
+	return "", i, errors.Wrap(ErrInvalidCurl, "unterminated quote")
+}
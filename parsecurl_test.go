@@ -0,0 +1,139 @@
+package requests
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseCurl(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var fields []string
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			_ = r.ParseMultipartForm(1 << 20)
+			for key, values := range r.MultipartForm.Value {
+				fields = append(fields, key+"="+values[0])
+			}
+			for key, files := range r.MultipartForm.File {
+				fields = append(fields, key+"@"+files[0].Filename)
+			}
+		} else {
+			_ = r.ParseForm()
+			for key, values := range r.PostForm {
+				fields = append(fields, key+"="+values[0])
+			}
+		}
+		sort.Strings(fields)
+		user, pass, _ := r.BasicAuth()
+		cookie, _ := r.Cookie("s")
+		if cookie == nil {
+			cookie = &http.Cookie{}
+		}
+		fmt.Fprintf(w, "%s %s x=%s auth=%s:%s cookie=%s %v", r.Method, r.URL.RequestURI(), r.Header.Get("X-Id"), user, pass, cookie.Value, fields)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{name: "get", command: `curl URL/path?a=1 -H 'X-Id: 1'`, want: "GET /path?a=1 x=1 auth=: cookie= []"},
+		{name: "data", command: `curl URL -d a=1 --data "b=2" -H "X-Id:  2"`, want: "POST / x=2 auth=: cookie= [a=1 b=2]"},
+		{name: "continuation", command: "curl \\\n  -XPUT \\\n  URL \\\n  --data-urlencode 'q=a b&c'", want: "PUT / x= auth=: cookie= [q=a b&c]"},
+		{name: "get data", command: `curl -G URL -d a=1 --data-urlencode b=2`, want: "GET /?a=1&b=2 x= auth=: cookie= []"},
+		{name: "form", command: `curl URL -F a=1 --form-string 'b=@2' -F 'f=@curl_test.go;filename=c.go'`, want: "POST / x= auth=: cookie= [a=1 b=@2 f@c.go]"},
+		{name: "auth cookie", command: `curl -sSL --user u:p -b 's=v; t=w' --url URL`, want: "GET / x= auth=u:p cookie=v []"},
+		{name: "ansi-c quote", command: `curl URL --data-raw $'a=it\'s' -H $'X-Id: \x31'`, want: "POST / x=1 auth=: cookie= [a=it's]"},
+		{name: "request equals", command: `curl --request=DELETE URL --compressed`, want: "DELETE / x= auth=: cookie= []"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := ParseCurl(strings.Replace(tt.command, "URL", srv.URL, 1))
+			if err != nil {
+				t.Fatalf("ParseCurl() err = %v", err)
+			}
+			resp, err := NewClient(cmd.ClientOptions()...).Request(cmd.Method, cmd.URL, cmd.Options...)
+			if err != nil {
+				t.Fatalf("Request() err = %v", err)
+			}
+			if got := resp.Text(); got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCurlErrors(t *testing.T) {
+	for _, command := range []string{
+		`wget http://example.com`,
+		`curl -o out http://example.com`,
+		`curl --proxy p http://example.com`,
+		`curl -H`,
+		`curl -H 'X-Id: 1'`,
+		`curl 'http://example.com`,
+		`curl http://example.com -d $'a=1`,
+		`curl -b cookies.txt http://example.com`,
+	} {
+		if _, err := ParseCurl(command); !errors.Is(err, ErrInvalidCurl) {
+			t.Errorf("ParseCurl(%q) err = %v, want ErrInvalidCurl", command, err)
+		}
+	}
+}
+
+func TestSplitShellANSIC(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: `$'{"a":"b"}'`, want: `{"a":"b"}`},
+		{word: `$'it\'s'`, want: "it's"},
+		{word: `$'a\nb\t\\c'`, want: "a\nb\t\\c"},
+		{word: `$'\x41\101\u00e9\U0001F600'`, want: "AAé😀"},
+		{word: `$'\q\x'`, want: `\q\x`},
+		{word: `x$'y'z`, want: "xyz"},
+	}
+	for _, tt := range tests {
+		args, err := splitShell(tt.word)
+		if err != nil || len(args) != 1 || args[0] != tt.want {
+			t.Errorf("splitShell(%s) got = %q, %v, want %q", tt.word, args, err, tt.want)
+		}
+	}
+}
+
+func TestParseCurlInsecure(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	cmd, err := ParseCurl("curl -k " + srv.URL)
+	if err != nil {
+		t.Fatalf("ParseCurl() err = %v", err)
+	}
+	if _, err := Get(srv.URL); err == nil {
+		t.Fatalf("Get() without -k err = nil")
+	}
+	client := NewClient(cmd.ClientOptions()...)
+	if _, err := client.Request(cmd.Method, cmd.URL, cmd.Options...); err != nil {
+		t.Errorf("Request() err = %v", err)
+	}
+	if config := http.DefaultTransport.(*http.Transport).TLSClientConfig; config != nil && config.InsecureSkipVerify {
+		t.Errorf("WithInsecureSkipVerify() changed http.DefaultTransport")
+	}
+}
+
+func TestParseCurlRoundTrip(t *testing.T) {
+	req := buildRequest(t, POST, "http://example.com/p?q=1", Json{"a": "it's"}, Header{"X-Id": "1"}, Cookies{"s": "v"})
+	cmd, err := ParseCurl(req.Curl())
+	if err != nil {
+		t.Fatalf("ParseCurl() err = %v", err)
+	}
+	parsed := buildRequest(t, cmd.Method, cmd.URL, cmd.Options...)
+	if got, want := parsed.Curl(), req.Curl(); got != want {
+		t.Errorf("Curl() of the parsed command = %v, want %v", got, want)
+	}
+}
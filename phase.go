@@ -0,0 +1,163 @@
+package requests
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// phaseTimeouts are the timeouts of the phases of a round trip, zero means no timeout.
+type phaseTimeouts struct {
+	connect        time.Duration
+	tlsHandshake   time.Duration
+	responseHeader time.Duration
+	read           time.Duration
+}
+
+// merge returns p overridden by the non-zero timeouts of o.
+func (p phaseTimeouts) merge(o phaseTimeouts) phaseTimeouts {
+	if o.connect != 0 {
+		p.connect = o.connect
+	}
+	if o.tlsHandshake != 0 {
+		p.tlsHandshake = o.tlsHandshake
+	}
+	if o.responseHeader != 0 {
+		p.responseHeader = o.responseHeader
+	}
+	if o.read != 0 {
+		p.read = o.read
+	}
+	return p
+}
+
+// phaseWatch times the phases of one round trip, following them through httptrace.
+// When a phase exceeds its timeout the round trip is canceled and the phase is remembered,
+// so that the resulting error can tell which one it was.
+type phaseWatch struct {
+	timeouts phaseTimeouts
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	gen     int   // incremented by every start and stop, so that a stale timer does nothing
+	expired error // the phase that timed out
+}
+
+// newPhaseWatch returns nil if there is no phase timeout.
+func newPhaseWatch(ctx context.Context, timeouts phaseTimeouts) *phaseWatch {
+	if timeouts == (phaseTimeouts{}) {
+		return nil
+	}
+	w := &phaseWatch{timeouts: timeouts}
+	w.ctx, w.cancel = context.WithCancel(ctx)
+	return w
+}
+
+// request returns a copy of req whose phases are watched.
+func (w *phaseWatch) request(req *http.Request) *http.Request {
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) { w.start(w.timeouts.connect, ErrConnectTimeout) },
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				w.stop()
+			}
+		},
+		TLSHandshakeStart: func() { w.start(w.timeouts.tlsHandshake, ErrTLSHandshakeTimeout) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { w.stop() },
+		GotConn:           func(httptrace.GotConnInfo) { w.stop() },
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			w.start(w.timeouts.responseHeader, ErrResponseHeaderTimeout)
+		},
+		GotFirstResponseByte: func() { w.stop() },
+	}
+	return req.WithContext(httptrace.WithClientTrace(w.ctx, trace))
+}
+
+func (w *phaseWatch) start(d time.Duration, phase error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopLocked()
+	if d <= 0 {
+		return
+	}
+	gen := w.gen
+	w.timer = time.AfterFunc(d, func() {
+		w.mu.Lock()
+		if w.gen != gen || w.expired != nil {
+			w.mu.Unlock()
+			return
+		}
+		w.expired = phase
+		w.mu.Unlock()
+		w.cancel()
+	})
+}
+
+func (w *phaseWatch) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopLocked()
+}
+
+func (w *phaseWatch) stopLocked() {
+	w.gen++
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+}
+
+// err returns err wrapped with the phase that timed out, if any.
+func (w *phaseWatch) err(err error) error {
+	if err == nil {
+		return nil
+	}
+	w.mu.Lock()
+	expired := w.expired
+	w.mu.Unlock()
+	if expired == nil {
+		return err
+	}
+	return &timeoutError{err: err, cause: expired}
+}
+
+// body returns body with the read timeout applied.
+func (w *phaseWatch) body(body io.ReadCloser) io.ReadCloser {
+	if w.timeouts.read <= 0 {
+		return body
+	}
+	w.start(w.timeouts.read, ErrReadTimeout)
+	return &idleReader{ReadCloser: body, watch: w}
+}
+
+// idleReader restarts the read timeout whenever bytes arrive.
+type idleReader struct {
+	io.ReadCloser
+	watch *phaseWatch
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err != nil {
+		r.watch.stop()
+		if err != io.EOF {
+			err = r.watch.err(err)
+		}
+		return n, err
+	}
+	if n > 0 {
+		r.watch.start(r.watch.timeouts.read, ErrReadTimeout)
+	}
+	return n, nil
+}
+
+func (r *idleReader) Close() error {
+	r.watch.stop()
+	return r.ReadCloser.Close()
+}
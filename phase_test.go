@@ -0,0 +1,96 @@
+package requests
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPhaseTimeouts(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 5; i++ {
+			w.Write([]byte("x"))
+			w.(http.Flusher).Flush()
+			time.Sleep(40 * time.Millisecond)
+		}
+	}))
+	defer slow.Close()
+
+	// accepts tcp connections without ever answering the tls handshake.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	slowDial := &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}}
+
+	tests := []struct {
+		name    string
+		client  *Client
+		url     string
+		opts    []ReqOption
+		wantErr error
+	}{
+		{name: "connect", client: NewClient(WithTransport(slowDial), WithConnectTimeout(50*time.Millisecond)), url: slow.URL, wantErr: ErrConnectTimeout},
+		{name: "connect request", client: NewClient(WithTransport(slowDial)), url: slow.URL, opts: []ReqOption{ConnectTimeout(50 * time.Millisecond)}, wantErr: ErrConnectTimeout},
+		{name: "connect ok", client: NewClient(WithTransport(slowDial), WithConnectTimeout(time.Second)), url: slow.URL},
+		{name: "tls handshake", client: NewClient(WithTLSHandshakeTimeout(50 * time.Millisecond)), url: "https://" + ln.Addr().String(), wantErr: ErrTLSHandshakeTimeout},
+		{name: "response header", client: NewClient(), url: testUrl + "/timeout", opts: []ReqOption{ResponseHeaderTimeout(50 * time.Millisecond)}, wantErr: ErrResponseHeaderTimeout},
+		{name: "read", client: NewClient(WithReadTimeout(20 * time.Millisecond)), url: slow.URL, wantErr: ErrReadTimeout},
+		{name: "read resets", client: NewClient(WithReadTimeout(100 * time.Millisecond)), url: slow.URL},
+		{name: "read override", client: NewClient(WithReadTimeout(20 * time.Millisecond)), url: slow.URL, opts: []ReqOption{ReadTimeout(time.Second)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.client.Get(tt.url, tt.opts...)
+			if tt.wantErr == nil {
+				if err != nil || resp.Text() != "xxxxx" {
+					t.Errorf("Get() err = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) || !errors.Is(err, ErrTimeout) {
+				t.Errorf("Get() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadTimeoutStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("x"))
+		w.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	resp, err := Get(srv.URL, Stream{}, ReadTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	defer resp.Close()
+	if _, err = resp.Bytes(); !errors.Is(err, ErrReadTimeout) {
+		t.Errorf("Bytes() err = %v, want ErrReadTimeout", err)
+	}
+}
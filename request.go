@@ -4,14 +4,16 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
-	"fmt"
+	"encoding/json"
+	"encoding/xml"
 	"github.com/ajg/form"
 	"github.com/pkg/errors"
 	"io"
 	"io/ioutil"
-	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 )
 
 type Request struct {
@@ -21,6 +23,38 @@ type Request struct {
 	json  Json
 	jsons Jsons
 	gzip  bool
+	// multipart sends form as multipart/form-data even without files.
+	multipart bool
+
+	jsonBody *jsonBody
+	xmlBody  *xmlBody
+	rawBody  *rawBody
+
+	stream  bool
+	timeout time.Duration
+	phases  phaseTimeouts
+	trace   bool
+	retry   *Retry
+	attempt int
+	hooks   []Hook
+	auth    authenticator
+	// authorization is the Authorization header set by an authenticator.
+	authorization string
+	codec         Codec
+
+	raiseForStatus *bool
+
+	// defaults is set while the options of WithDefaultOptions are applied, defaultParams are
+	// the query parameters they set.
+	defaults      bool
+	defaultParams map[string]bool
+
+	// secretHeaders and secretParams name the credentials redacted when the request is logged.
+	secretHeaders []string
+	secretParams  []string
+
+	// consumed reports whether the body was handed to the transport, which closes it, or closed.
+	consumed bool
 }
 
 // NewRequest wraps NewRequestWithContext using the background context.
@@ -33,20 +67,60 @@ func NewRequest(method, url string) (*Request, error) {
 	return &Request{Request: r}, nil
 }
 
+// hasBody reports whether a body option other than Json, Jsons, Form and files was applied,
+// such bodies can not be combined with any other.
+func (req *Request) hasBody() bool {
+	return req.jsonBody != nil || req.xmlBody != nil || req.rawBody != nil
+}
+
+// hasAnyBody reports whether any body option was applied.
+func (req *Request) hasAnyBody() bool {
+	return req.files != nil || req.form != nil || req.json != nil || req.jsons != nil || req.hasBody()
+}
+
 func (req *Request) loadBody() error {
-	if req.files == nil && req.form == nil && req.json == nil && req.jsons == nil {
+	if !req.hasAnyBody() {
 		return nil
 	}
-	// application/json
-	var jsonData interface{}
-	if req.jsons != nil {
-		jsonData = req.jsons
-	} else if req.json != nil {
-		jsonData = req.json
+	// raw body
+	if raw := req.rawBody; raw != nil {
+		if raw.contentType != "" {
+			req.Header.Set("content-Type", raw.contentType)
+		}
+		if raw.data != nil && req.gzip {
+			data, err := req.compressed(raw.data)
+			if err != nil {
+				return err
+			}
+			req.setBody(data)
+			return nil
+		}
+		open, size := raw.open, raw.size
+		if req.gzip {
+			open, size = gzipBody(open), -1
+			req.setGzipHeader()
+		}
+		return req.setBodyFunc(open, size, raw.replayable())
 	}
-	if jsonData != nil {
+	// application/xml
+	if req.xmlBody != nil {
+		req.Header.Set("content-Type", "application/xml")
+		xmlBytes, err := req.encodeXml()
+		if err != nil {
+			return errors.Wrap(ErrInvalidXml, err.Error())
+		}
+		if req.gzip {
+			if xmlBytes, err = req.compressed(xmlBytes); err != nil {
+				return err
+			}
+		}
+		req.setBody(xmlBytes)
+		return nil
+	}
+	// application/json
+	if req.json != nil || req.jsons != nil || req.jsonBody != nil {
 		req.Header.Set("content-Type", "application/json")
-		jsonBytes, err := marshal(jsonData)
+		jsonBytes, err := req.encodeJson()
 		if err != nil {
 			return errors.Wrap(ErrInvalidJson, err.Error())
 		}
@@ -55,12 +129,11 @@ func (req *Request) loadBody() error {
 				return err
 			}
 		}
-		buf := bytes.NewBuffer(jsonBytes)
-		req.Body = ioutil.NopCloser(buf)
+		req.setBody(jsonBytes)
 		return nil
 	}
 	// application/x-www-form-urlencoded
-	if req.files == nil {
+	if req.files == nil && !req.multipart {
 		req.Header.Set("content-Type", "application/x-www-form-urlencoded")
 		data, err := form.EncodeToString(req.form)
 		if err != nil {
@@ -73,45 +146,110 @@ func (req *Request) loadBody() error {
 				data = string(compressedData)
 			}
 		}
-		dataReader := strings.NewReader(data)
-		req.Body = ioutil.NopCloser(dataReader)
+		req.setBody([]byte(data))
 		return nil
 	}
 	// multipart/form-data; boundary=b...
-	buffer := &bytes.Buffer{}
-	multipartWriter := multipart.NewWriter(buffer)
-	for _, file := range req.files {
-		writer, err := multipartWriter.CreateFormFile(file.field, file.name)
-		if err != nil {
-			return errors.Wrap(ErrInvalidFile, fmt.Sprintf("field: %s, name: %s, CreateFormFile err: %v", file.field, file.name, err))
-		}
-		if _, err = io.Copy(writer, file.content); err != nil && err != io.EOF {
-			return errors.Wrap(ErrInvalidFile, fmt.Sprintf("field: %s, name: %s, Copy err: %v", file.field, file.name, err))
-		}
-		if err = file.content.Close(); err != nil {
-			return errors.Wrap(ErrInvalidFile, fmt.Sprintf("field: %s, name: %s, Close err: %v", file.field, file.name, err))
-		}
+	body, err := newMultipartBody(req.files, req.form)
+	if err != nil {
+		return err
 	}
-	for k, v := range req.form {
-		if err := multipartWriter.WriteField(k, v); err != nil {
-			return errors.Wrap(ErrInvalidForm, fmt.Sprintf("Key: %s, Value: %s, WriteField err: %v", k, v, err))
+	open, size := body.open, body.size()
+	if req.gzip {
+		open, size = gzipBody(open), -1
+		req.setGzipHeader()
+	}
+	req.Header.Add("content-Type", body.contentType)
+	return req.setBodyFunc(open, size, body.replayable())
+}
+
+// encodeJson encodes the json body with the request Codec.
+func (req *Request) encodeJson() ([]byte, error) {
+	var jsonData interface{}
+	if req.jsons != nil {
+		jsonData = req.jsons
+	} else if req.json != nil {
+		jsonData = req.json
+	} else {
+		switch v := req.jsonBody.value.(type) {
+		case json.RawMessage:
+			return v, nil
+		case []byte:
+			return v, nil
 		}
+		jsonData = req.jsonBody.value
 	}
-	if err := multipartWriter.Close(); err != nil {
+	return codecOrDefault(req.codec).Marshal(jsonData)
+}
+
+// encodeXml encodes the xml body, prefixed with the xml declaration.
+func (req *Request) encodeXml() ([]byte, error) {
+	if data, ok := req.xmlBody.value.([]byte); ok {
+		return data, nil
+	}
+	data, err := xml.Marshal(req.xmlBody.value)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// setBody installs data as the request body and makes it replayable through GetBody.
+func (req *Request) setBody(data []byte) {
+	_ = req.setBodyFunc(func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}, int64(len(data)), true)
+}
+
+// setBodyFunc installs the body returned by open, size is -1 if unknown, which sends it chunked.
+// Replayable bodies can be opened again through GetBody.
+func (req *Request) setBodyFunc(open func() (io.ReadCloser, error), size int64, replayable bool) error {
+	body, err := open()
+	if err != nil {
 		return err
 	}
-	if req.gzip {
-		if compressedData, err := req.compressed(buffer.Bytes()); err != nil {
-			return err
-		} else {
-			buffer = bytes.NewBuffer(compressedData)
-		}
+	req.Body = body
+	req.ContentLength = size
+	req.GetBody = nil
+	if replayable {
+		req.GetBody = open
+	}
+	return nil
+}
+
+// replayable reports whether the request body can be sent again.
+func (req *Request) replayable() bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewind restores the request body before it is sent again.
+func (req *Request) rewind() error {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
 	}
-	req.Body = ioutil.NopCloser(buffer)
-	req.Header.Add("content-Type", multipartWriter.FormDataContentType())
+	req.Body = body
+	req.consumed = false
 	return nil
 }
 
+// closeUnsent closes a body that never reached the transport, e.g. when a middleware answered
+// the request itself or authorizing it failed, as the transport would have.
+func (req *Request) closeUnsent() {
+	if !req.consumed && req.Body != nil {
+		_ = req.Body.Close()
+	}
+	req.consumed = true
+}
+
+// Attempt returns the 1-based number of the attempt being processed, it is greater than 1 on retries.
+func (req *Request) Attempt() int {
+	return req.attempt
+}
+
 func (req *Request) compressed(data []byte) ([]byte, error) {
 	var buf bytes.Buffer
 	gw := gzip.NewWriter(&buf)
@@ -122,7 +260,29 @@ func (req *Request) compressed(data []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Encoding", "gzip")
-	req.Header.Set("Accept-Encoding", "gzip")
+	req.setGzipHeader()
 	return buf.Bytes(), nil
 }
+
+func (req *Request) setGzipHeader() {
+	req.Header.Set("Content-Encoding", "gzip")
+}
+
+// removeParam removes the pairs of the query parameter key, leaving the others untouched.
+func (req *Request) removeParam(key string) {
+	pairs := strings.Split(req.URL.RawQuery, "&")
+	kept := pairs[:0]
+	for _, pair := range pairs {
+		name := pair
+		if i := strings.IndexByte(pair, '='); i >= 0 {
+			name = pair[:i]
+		}
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if name != key {
+			kept = append(kept, pair)
+		}
+	}
+	req.URL.RawQuery = strings.Join(kept, "&")
+}
@@ -1,37 +1,108 @@
 package requests
 
 import (
-	"compress/gzip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/htmlindex"
 )
 
 // Response is the wrapper for http.Response
 type Response struct {
 	*http.Response
-	bytes []byte
+	// Timings is recorded for requests sent with Trace or by a client created with WithTrace.
+	Timings *Timings
+
+	bytes    []byte
+	decoded  bool
+	encoding string
+	decoders map[string]Decoder
+	codec    Codec
+	request  *Request
 }
 
 func NewResponse(r *http.Response) (*Response, error) {
-	resp := &Response{Response: r}
+	return newResponse(r, nil)
+}
+
+// newResponse reads the body of r, decoded with decoders, the built-in ones if nil.
+func newResponse(r *http.Response, decoders map[string]Decoder) (*Response, error) {
+	resp := &Response{Response: r, decoders: decoders}
 	_, err := resp.Bytes()
-	_ = r.Body.Close()
 	return resp, err
 }
 
+// newStreamResponse wraps r without reading its body, the caller must Close it.
+func newStreamResponse(r *http.Response, decoders map[string]Decoder) (*Response, error) {
+	resp := &Response{Response: r, decoders: decoders}
+	if err := resp.decode(); err != nil {
+		_ = r.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Text returns the response body decoded to UTF-8 from the charset given by SetEncoding,
+// the Content-Type header or the HTML meta or XML declaration of the body, in that order.
+// The body is returned undecoded if the charset is not recognized.
 func (r *Response) Text() string {
-	return string(r.bytes)
+	data, _ := r.Bytes()
+	if text, err := decodeCharset(r.Encoding(), data); err == nil {
+		return string(text)
+	}
+	return string(data)
+}
+
+// TextWithEncoding returns the response body decoded from the charset encoding,
+// an unknown encoding returns ErrUnrecognizedEncoding.
+func (r *Response) TextWithEncoding(encoding string) (string, error) {
+	data, err := r.Bytes()
+	if err != nil {
+		return "", err
+	}
+	text, err := decodeCharset(encoding, data)
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+// SetEncoding overrides the charset Text decodes the body from, an unknown encoding
+// returns ErrUnrecognizedEncoding.
+func (r *Response) SetEncoding(encoding string) error {
+	if _, err := htmlindex.Get(encoding); err != nil {
+		return errors.Wrap(ErrUnrecognizedEncoding, encoding)
+	}
+	r.encoding = encoding
+	return nil
+}
+
+// Encoding returns the charset Text decodes the body from, utf-8 if none is declared.
+func (r *Response) Encoding() string {
+	if r.encoding != "" {
+		return r.encoding
+	}
+	if label := contentTypeCharset(r.Header.Get("Content-Type")); label != "" {
+		return label
+	}
+	if label := sniffCharset(r.bytes); label != "" {
+		return label
+	}
+	return "utf-8"
 }
 
+// Bytes returns the response body, reading and closing it on the first call.
 func (r *Response) Bytes() ([]byte, error) {
 	if r.bytes == nil {
-		var err error
-		if r.Header.Get("Content-Encoding") == "gzip" {
-			if r.Body, err = r.decompressed(r.Body); err != nil {
-				return nil, err
-			}
+		defer r.Body.Close()
+		if err := r.decode(); err != nil {
+			return nil, err
 		}
 		data, err := ioutil.ReadAll(r.Body)
 		if err != nil {
@@ -42,13 +113,72 @@ func (r *Response) Bytes() ([]byte, error) {
 	return r.bytes, nil
 }
 
+// RaiseForStatus returns an *HTTPError if the status code is not 2xx.
+func (r *Response) RaiseForStatus() error {
+	if r.StatusCode >= 200 && r.StatusCode < 300 {
+		return nil
+	}
+	e := &HTTPError{StatusCode: r.StatusCode, Header: r.Header}
+	if r.request != nil {
+		e.Method, e.URL = r.request.Method, r.request.redactedURL()
+	} else if r.Request != nil {
+		e.Method, e.URL = r.Request.Method, r.Request.URL.Redacted()
+	}
+	if len(r.bytes) > maxErrorBody {
+		e.Body = r.bytes[:maxErrorBody]
+	} else {
+		e.Body = r.bytes
+	}
+	return e
+}
+
+// Close closes the response body, it must be called for responses of requests sent with Stream.
+func (r *Response) Close() error {
+	return r.Body.Close()
+}
+
+// closeWith calls f once the body is closed.
+func (r *Response) closeWith(f func()) {
+	body := r.Body
+	r.Body = &readCloser{Reader: body, Closer: closerFunc(func() error {
+		err := body.Close()
+		f()
+		return err
+	})}
+}
+
 // Json could parse http json response
-func (r Response) Json(s interface{}) error {
-	return unmarshal(r.bytes, s)
+func (r *Response) Json(s interface{}) error {
+	data, err := r.Bytes()
+	if err != nil {
+		return err
+	}
+	return codecOrDefault(r.codec).Unmarshal(data, s)
+}
+
+// Xml could parse http xml response, documents in other charsets than UTF-8 are decoded
+// according to the Content-Type charset or the encoding of the xml declaration.
+func (r *Response) Xml(v interface{}) error {
+	data, err := r.Bytes()
+	if err != nil {
+		return err
+	}
+	var reader io.Reader = bytes.NewReader(data)
+	charset := charsetReader
+	if label := contentTypeCharset(r.Header.Get("Content-Type")); label != "" {
+		// the charset of the Content-Type header takes precedence over the xml declaration.
+		if reader, err = charsetReader(label, reader); err != nil {
+			return err
+		}
+		charset = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	}
+	decoder := xml.NewDecoder(reader)
+	decoder.CharsetReader = charset
+	return decoder.Decode(v)
 }
 
 // SaveFile save bytes data to a local file
-func (r Response) SaveFile(filename string) error {
+func (r *Response) SaveFile(filename string) error {
 	dst, err := os.Create(filename)
 	if err != nil {
 		return err
@@ -57,14 +187,71 @@ func (r Response) SaveFile(filename string) error {
 		_ = dst.Close()
 	}()
 
-	if bt, err := r.Bytes(); err != nil {
+	if r.bytes != nil {
+		_, err = dst.Write(r.bytes)
 		return err
-	} else {
-		_, err = dst.Write(bt)
+	}
+	defer r.Body.Close()
+	if err = r.decode(); err != nil {
 		return err
 	}
+	_, err = io.Copy(dst, r.Body)
+	return err
+}
+
+// decode makes Body yield the content decoded according to Content-Encoding, once. Stacked
+// codings are decoded in reverse order, the body is left as is if one of them is unknown.
+// Like http.Transport, Content-Encoding and Content-Length are removed once decoded.
+func (r *Response) decode() error {
+	if r.decoded {
+		return nil
+	}
+	r.decoded = true
+	codings := contentCodings(r.Header.Values("Content-Encoding"))
+	if len(codings) == 0 {
+		return nil
+	}
+	decoders := r.decoders
+	if decoders == nil {
+		decoders = builtinDecoders
+	}
+	for _, coding := range codings {
+		if decoders[coding] == nil {
+			return nil
+		}
+	}
+	body := bufio.NewReader(r.Body)
+	if _, err := body.Peek(1); err == io.EOF {
+		// e.g. the response of a HEAD request.
+		return nil
+	}
+	var reader io.Reader = body
+	closers := multiCloser{r.Body}
+	for i := len(codings) - 1; i >= 0; i-- {
+		decoded, err := decoders[codings[i]](reader)
+		if err != nil {
+			return err
+		}
+		reader = decoded
+		// the outer decoders are closed first.
+		closers = append(multiCloser{decoded}, closers...)
+	}
+	r.Body = &readCloser{Reader: reader, Closer: closers}
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	r.ContentLength = -1
+	r.Uncompressed = true
+	return nil
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
 }
 
-func (r *Response) decompressed(reader io.Reader) (io.ReadCloser, error) {
-	return gzip.NewReader(reader)
+// readCloser reads from Reader and closes Closer, so that closing a decompressed body closes the connection.
+type readCloser struct {
+	io.Reader
+	io.Closer
 }
@@ -0,0 +1,212 @@
+package requests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+func TestStream(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("second"))
+	}))
+	defer srv.Close()
+
+	resp, err := Get(srv.URL, Stream{})
+	if err != nil {
+		t.Fatalf("Stream() err = %v", err)
+	}
+	defer resp.Close()
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(resp.Body, buf); err != nil {
+		t.Fatalf("Stream() read err = %v", err)
+	}
+	if got := string(buf); got != "first" {
+		t.Errorf("Stream() got = %v, want %v", got, "first")
+	}
+	close(release)
+	if got := resp.Text(); got != "second" {
+		t.Errorf("Stream() Text() = %v, want %v", got, "second")
+	}
+}
+
+func TestStreamGzip(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gw := gzip.NewWriter(w)
+		gw.Write([]byte(`{"a":"1"}`))
+		gw.Close()
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		opts []ReqOption
+	}{
+		{name: "buffered", opts: []ReqOption{Header{"Accept-Encoding": "gzip"}}},
+		{name: "stream", opts: []ReqOption{Header{"Accept-Encoding": "gzip"}, Stream{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := Get(srv.URL, tt.opts...)
+			if err != nil {
+				t.Fatalf("Get() err = %v", err)
+			}
+			defer resp.Close()
+			got := map[string]string{}
+			if err := resp.Json(&got); err != nil {
+				t.Fatalf("Json() err = %v", err)
+			}
+			if got["a"] != "1" {
+				t.Errorf("Json() got = %v", got)
+			}
+		})
+	}
+}
+
+func TestStreamSaveFile(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1<<16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "go-requests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "out")
+
+	resp, err := Get(srv.URL, Stream{})
+	if err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	if err := resp.SaveFile(filename); err != nil {
+		t.Fatalf("SaveFile() err = %v", err)
+	}
+	got, _ := ioutil.ReadFile(filename)
+	if !bytes.Equal(got, content) {
+		t.Errorf("SaveFile() wrote %d bytes, want %d", len(got), len(content))
+	}
+}
+
+func TestXml(t *testing.T) {
+	type item struct {
+		Name string `xml:"name"`
+	}
+	gbk := func(s string) []byte {
+		data, _ := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(s))
+		return data
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		gzip        bool
+		body        []byte
+	}{
+		{name: "utf-8", contentType: "application/xml", body: []byte(`<item><name>中文</name></item>`)},
+		{name: "gzip", contentType: "application/xml", gzip: true, body: []byte(`<item><name>中文</name></item>`)},
+		{name: "declaration", contentType: "application/xml", body: gbk(`<?xml version="1.0" encoding="GBK"?><item><name>中文</name></item>`)},
+		{name: "content-type", contentType: "text/xml; charset=gb18030", body: gbk(`<item><name>中文</name></item>`)},
+		{name: "content-type wins", contentType: "text/xml; charset=gbk", body: gbk(`<?xml version="1.0" encoding="ISO-8859-1"?><item><name>中文</name></item>`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				if tt.gzip {
+					w.Header().Set("Content-Encoding", "gzip")
+					gw := gzip.NewWriter(w)
+					gw.Write(tt.body)
+					gw.Close()
+					return
+				}
+				w.Write(tt.body)
+			}))
+			defer srv.Close()
+			resp, err := Get(srv.URL, Header{"Accept-Encoding": "gzip"})
+			if err != nil {
+				t.Fatalf("Get() err = %v", err)
+			}
+			var got item
+			if err := resp.Xml(&got); err != nil {
+				t.Fatalf("Xml() err = %v", err)
+			}
+			if got.Name != "中文" {
+				t.Errorf("Xml() got = %v, want 中文", got.Name)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	encode := func(e encoding.Encoding, s string) []byte {
+		data, _ := e.NewEncoder().Bytes([]byte(s))
+		return data
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		want        string
+		encoding    string
+	}{
+		{name: "utf-8", contentType: "text/plain", body: []byte("中文"), want: "中文", encoding: "utf-8"},
+		{name: "utf-8 bom", contentType: "text/plain; charset=utf-8", body: []byte("\xef\xbb\xbf中文"), want: "中文", encoding: "utf-8"},
+		{name: "gbk", contentType: "text/plain; charset=GBK", body: encode(simplifiedchinese.GBK, "中文"), want: "中文", encoding: "GBK"},
+		{name: "gb18030", contentType: "application/json; charset=gb18030", body: encode(simplifiedchinese.GB18030, `{"a":"中文"}`), want: `{"a":"中文"}`, encoding: "gb18030"},
+		{name: "big5", contentType: "text/plain; charset=big5", body: encode(traditionalchinese.Big5, "中文"), want: "中文", encoding: "big5"},
+		{name: "latin1", contentType: "text/plain; charset=iso-8859-1", body: []byte("caf\xe9"), want: "café", encoding: "iso-8859-1"},
+		{name: "html meta", contentType: "text/html", body: encode(simplifiedchinese.GBK, `<html><head><meta charset="gbk"></head>中文</html>`), want: `<html><head><meta charset="gbk"></head>中文</html>`, encoding: "gbk"},
+		{name: "html http-equiv", contentType: "text/html", body: encode(simplifiedchinese.GBK, `<meta http-equiv="Content-Type" content="text/html; charset=gb2312">中文`), want: `<meta http-equiv="Content-Type" content="text/html; charset=gb2312">中文`, encoding: "gb2312"},
+		{name: "xml declaration", contentType: "application/xml", body: encode(simplifiedchinese.GBK, `<?xml version="1.0" encoding="GBK"?><a>中文</a>`), want: `<?xml version="1.0" encoding="GBK"?><a>中文</a>`, encoding: "GBK"},
+		{name: "unknown", contentType: "text/plain; charset=unknown", body: []byte("raw"), want: "raw", encoding: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &Response{Response: &http.Response{Header: http.Header{"Content-Type": {tt.contentType}}}, bytes: tt.body}
+			if got := resp.Text(); got != tt.want {
+				t.Errorf("Text() got = %v, want %v", got, tt.want)
+			}
+			if got := resp.Encoding(); got != tt.encoding {
+				t.Errorf("Encoding() got = %v, want %v", got, tt.encoding)
+			}
+		})
+	}
+}
+
+func TestSetEncoding(t *testing.T) {
+	body, _ := simplifiedchinese.GBK.NewEncoder().Bytes([]byte("中文"))
+	resp := &Response{Response: &http.Response{Header: http.Header{"Content-Type": {"text/plain; charset=utf-8"}}}, bytes: body}
+	if err := resp.SetEncoding("nope"); !errors.Is(err, ErrUnrecognizedEncoding) {
+		t.Errorf("SetEncoding() err = %v, want ErrUnrecognizedEncoding", err)
+	}
+	if err := resp.SetEncoding("gbk"); err != nil {
+		t.Fatalf("SetEncoding() err = %v", err)
+	}
+	if got := resp.Text(); got != "中文" {
+		t.Errorf("Text() got = %v, want 中文", got)
+	}
+	if got, err := resp.TextWithEncoding("gb18030"); err != nil || got != "中文" {
+		t.Errorf("TextWithEncoding() got = %v, err = %v", got, err)
+	}
+	if _, err := resp.TextWithEncoding("nope"); !errors.Is(err, ErrUnrecognizedEncoding) {
+		t.Errorf("TextWithEncoding() err = %v, want ErrUnrecognizedEncoding", err)
+	}
+}
@@ -0,0 +1,178 @@
+package requests
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultRetryStatusCodes are the response status codes retried when Retry.StatusCodes is nil.
+var DefaultRetryStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = 100 * time.Millisecond
+	defaultRetryMaxDelay    = 30 * time.Second
+)
+
+// Backoff returns how long to wait before the given retry, attempt starts at 1.
+type Backoff func(attempt int) time.Duration
+
+// RetryPolicy reports whether a finished attempt should be retried.
+type RetryPolicy func(resp *Response, err error) bool
+
+// ExponentialBackoff returns a Backoff with full jitter: the n-th retry waits
+// a random duration in [0, min(max, base*2^(n-1))).
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		ceil := max
+		if attempt < 32 {
+			if d := base << uint(attempt-1); d > 0 && d < max {
+				ceil = d
+			}
+		}
+		if ceil <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(ceil)))
+	}
+}
+
+// Retry describes how a request is retried. It can be used as a ReqOption,
+// or as the client default through WithRetry. The zero value retries up to
+// 3 attempts on connection errors, timeouts and DefaultRetryStatusCodes.
+type Retry struct {
+	// MaxAttempts is the total number of attempts including the first one, default 3.
+	MaxAttempts int
+	// BaseDelay and MaxDelay configure the default ExponentialBackoff, default 100ms and 30s.
+	// A Retry-After response header longer than MaxDelay stops retrying.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// StatusCodes are the response status codes to retry, default DefaultRetryStatusCodes.
+	StatusCodes []int
+	// Backoff replaces the default ExponentialBackoff.
+	Backoff Backoff
+	// Policy replaces the default decision of which attempts are retried.
+	Policy RetryPolicy
+}
+
+func (r Retry) Do(req *Request) error {
+	req.retry = &r
+	return nil
+}
+
+func (r *Retry) maxAttempts() int {
+	if r.MaxAttempts <= 0 {
+		return defaultRetryMaxAttempts
+	}
+	return r.MaxAttempts
+}
+
+func (r *Retry) maxDelay() time.Duration {
+	if r.MaxDelay <= 0 {
+		return defaultRetryMaxDelay
+	}
+	return r.MaxDelay
+}
+
+// retryable reports whether req should be attempted again after the attempt that produced resp and err.
+func (r *Retry) retryable(req *Request, resp *Response, err error) bool {
+	if r == nil || req.attempt >= r.maxAttempts() || req.Context().Err() != nil || !req.replayable() {
+		return false
+	}
+	if r.Policy != nil {
+		return r.Policy(resp, err)
+	}
+	if err != nil {
+		return isRetryableError(err)
+	}
+	codes := r.StatusCodes
+	if codes == nil {
+		codes = DefaultRetryStatusCodes
+	}
+	for _, code := range codes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns how long to wait before the next attempt, ok is false when
+// the server asked to wait longer than MaxDelay.
+func (r *Retry) delay(attempt int, resp *Response) (d time.Duration, ok bool) {
+	if resp != nil {
+		if d, found := retryAfter(resp.Header.Get("Retry-After")); found {
+			return d, d <= r.maxDelay()
+		}
+	}
+	backoff := r.Backoff
+	if backoff == nil {
+		base := r.BaseDelay
+		if base <= 0 {
+			base = defaultRetryBaseDelay
+		}
+		backoff = ExponentialBackoff(base, r.maxDelay())
+	}
+	return backoff(attempt), true
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		d := time.Until(at)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// isRetryableError reports whether err is a timeout or a connection level failure.
+func isRetryableError(err error) bool {
+	if errors.Is(err, ErrTimeout) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
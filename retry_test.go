@@ -0,0 +1,113 @@
+package requests
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first `failures` requests with status and echoes the body afterwards.
+func flakyServer(failures int32, status int, header http.Header) (*httptest.Server, *int32) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if atomic.AddInt32(&calls, 1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write(body)
+	}))
+	return srv, &calls
+}
+
+func TestRetry(t *testing.T) {
+	type args struct {
+		failures int32
+		status   int
+		header   http.Header
+		opts     []ReqOption
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantStatus int
+		wantCalls  int32
+	}{
+		{name: "no retry", args: args{failures: 1, status: 503}, wantStatus: 503, wantCalls: 1},
+		{name: "503", args: args{failures: 2, status: 503, opts: []ReqOption{Retry{BaseDelay: time.Millisecond}}}, wantStatus: 200, wantCalls: 3},
+		{name: "exhausted", args: args{failures: 5, status: 502, opts: []ReqOption{Retry{MaxAttempts: 2, BaseDelay: time.Millisecond}}}, wantStatus: 502, wantCalls: 2},
+		{name: "not retryable", args: args{failures: 1, status: 500, opts: []ReqOption{Retry{BaseDelay: time.Millisecond}}}, wantStatus: 500, wantCalls: 1},
+		{name: "custom codes", args: args{failures: 1, status: 500, opts: []ReqOption{Retry{StatusCodes: []int{500}, BaseDelay: time.Millisecond}}}, wantStatus: 200, wantCalls: 2},
+		{name: "json body", args: args{failures: 1, status: 429, opts: []ReqOption{Json{"a": "1"}, Retry{BaseDelay: time.Millisecond}}}, wantStatus: 200, wantCalls: 2},
+		{name: "gzip file body", args: args{failures: 1, status: 429, opts: []ReqOption{Gzip{}, FileWithContent("f", "f.txt", []byte("hi")), Retry{BaseDelay: time.Millisecond}}}, wantStatus: 200, wantCalls: 2},
+		{name: "retry-after too long", args: args{failures: 1, status: 429, header: http.Header{"Retry-After": {"60"}}, opts: []ReqOption{Retry{MaxDelay: time.Second}}}, wantStatus: 429, wantCalls: 1},
+		{name: "retry-after", args: args{failures: 1, status: 429, header: http.Header{"Retry-After": {"0"}}, opts: []ReqOption{Retry{}}}, wantStatus: 200, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := flakyServer(tt.args.failures, tt.args.status, tt.args.header)
+			defer srv.Close()
+			resp, err := Post(srv.URL, tt.args.opts...)
+			if err != nil {
+				t.Fatalf("Retry() err = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Retry() status = %v, want %v", resp.StatusCode, tt.wantStatus)
+			}
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("Retry() calls = %v, want %v", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryReplaysBody(t *testing.T) {
+	srv, _ := flakyServer(2, 503, nil)
+	defer srv.Close()
+	resp, err := Post(srv.URL, Form{"a": "1"}, Retry{BaseDelay: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Retry() err = %v", err)
+	}
+	if got := resp.Text(); got != "a=1" {
+		t.Errorf("Retry() body = %v, want %v", got, "a=1")
+	}
+}
+
+func TestRetryConnectionError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+	var attempts []int
+	client := NewClient(WithRetry(Retry{MaxAttempts: 3, BaseDelay: time.Millisecond}))
+	client.AddHook(attemptHook(func(req *Request) { attempts = append(attempts, req.Attempt()) }))
+	if _, err := client.Get(url); err == nil {
+		t.Fatalf("Retry() err = nil, want connection error")
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(attempts, want) {
+		t.Errorf("Retry() attempts = %v, want %v", attempts, want)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	for attempt, ceil := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 40 * time.Millisecond, 4: 50 * time.Millisecond, 100: 50 * time.Millisecond} {
+		for i := 0; i < 100; i++ {
+			if d := backoff(attempt); d < 0 || d >= ceil {
+				t.Fatalf("ExponentialBackoff(%d) = %v, want in [0, %v)", attempt, d, ceil)
+			}
+		}
+	}
+}
+
+type attemptHook func(req *Request)
+
+func (h attemptHook) BeforeProcess(req *Request) { h(req) }
+
+func (h attemptHook) AfterProcess(req *Request, resp *Response, err error) {}
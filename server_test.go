@@ -2,8 +2,10 @@ package requests
 
 import (
 	"compress/gzip"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"testing"
@@ -22,7 +24,7 @@ func getHandler(w http.ResponseWriter, r *http.Request) {
 	for k, v := range query {
 		params[k] = v[0]
 	}
-	body, _ := marshal(params)
+	body, _ := json.Marshal(params)
 	w.Write(body)
 }
 
@@ -47,7 +49,7 @@ func postHandler(w http.ResponseWriter, r *http.Request) {
 		for k, v := range r.PostForm {
 			data[k] = v[0]
 		}
-		body, _ := marshal(data)
+		body, _ := json.Marshal(data)
 		w.Write(body)
 		return
 	default:
@@ -68,7 +70,7 @@ func timeoutHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func headerHandler(w http.ResponseWriter, r *http.Request) {
-	bytes, _ := marshal(r.Header)
+	bytes, _ := json.Marshal(r.Header)
 	w.Write(bytes)
 }
 
@@ -116,8 +118,12 @@ func TestMain(m *testing.M) {
 	http.HandleFunc("/timeout", timeoutHandler)
 	http.HandleFunc("/header", headerHandler)
 	http.HandleFunc("/upload", uploadFile)
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		panic(err)
+	}
 	go func() {
-		if err := http.ListenAndServe(fmt.Sprintf(":%d", port), nil); err != nil {
+		if err := http.Serve(ln, nil); err != nil {
 			panic(err)
 		}
 	}()
@@ -0,0 +1,114 @@
+package requests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSessionCookies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cr3t", Path: "/"})
+		case "/me":
+			cookie, err := r.Cookie("session")
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(cookie.Value))
+		}
+	}))
+	defer srv.Close()
+
+	session := NewSession(WithBaseURL(srv.URL))
+	if _, err := session.Post("/login"); err != nil {
+		t.Fatalf("login err = %v", err)
+	}
+	resp, err := session.Get("me")
+	if err != nil {
+		t.Fatalf("me err = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Text() != "s3cr3t" {
+		t.Errorf("me got = %v %v, want 200 s3cr3t", resp.StatusCode, resp.Text())
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		base string
+		url  string
+		want string
+	}{
+		{base: "", url: "http://a.com/x", want: "http://a.com/x"},
+		{base: "http://a.com/api", url: "users", want: "http://a.com/api/users"},
+		{base: "http://a.com/api/", url: "/users", want: "http://a.com/api/users"},
+		{base: "http://a.com/api", url: "", want: "http://a.com/api"},
+		{base: "http://a.com/api", url: "?a=1", want: "http://a.com/api?a=1"},
+		{base: "http://a.com/api", url: "http://b.com/x", want: "http://b.com/x"},
+		{base: "http://a.com/api", url: "/login?next=https://b.com", want: "http://a.com/api/login?next=https://b.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.base+"|"+tt.url, func(t *testing.T) {
+			client := NewClient(WithBaseURL(tt.base))
+			if got := client.resolveURL(tt.url); got != tt.want {
+				t.Errorf("resolveURL() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultOptions(t *testing.T) {
+	client := NewClient(
+		WithBaseURL(testUrl),
+		WithDefaultOptions(Params{"a": "default", "b": "default"}, Header{"X-A": "default"}),
+	)
+	type args struct {
+		url  string
+		opts []ReqOption
+	}
+	tests := []struct {
+		name string
+		args args
+		want map[string]string
+	}{
+		{name: "defaults", args: args{url: "/get"}, want: map[string]string{"a": "default", "b": "default"}},
+		{name: "override", args: args{url: "/get", opts: []ReqOption{Params{"a": "call"}}}, want: map[string]string{"a": "call", "b": "default"}},
+		{name: "url query", args: args{url: "/get?c=url"}, want: map[string]string{"a": "default", "b": "default", "c": "url"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Get(tt.args.url, tt.args.opts...)
+			if err != nil {
+				t.Fatalf("Get() err = %v", err)
+			}
+			got := map[string]string{}
+			_ = resp.Json(&got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	resp, _ := client.Get("/header", Header{"X-A": "call"})
+	got := map[string][]string{}
+	_ = resp.Json(&got)
+	if !reflect.DeepEqual(got["X-A"], []string{"call"}) {
+		t.Errorf("Header got = %v, want [call]", got["X-A"])
+	}
+}
+
+func TestCookiesOverride(t *testing.T) {
+	req, _ := NewRequest(GET, testUrl)
+	_ = Cookies{"a": "1", "b": "1"}.Do(req)
+	_ = Cookies{"a": "2"}.Do(req)
+	got := map[string]string{}
+	for _, c := range req.Cookies() {
+		got[c.Name] = c.Value
+	}
+	if want := map[string]string{"a": "2", "b": "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Cookies got = %v, want %v", got, want)
+	}
+}
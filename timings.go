@@ -0,0 +1,126 @@
+package requests
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings is the duration of the phases of a request, it is recorded in Response.Timings
+// when the request is sent with Trace or by a client created with WithTrace.
+type Timings struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// Wait is the time between writing the request and the first response byte.
+	Wait time.Duration
+	// ContentTransfer is the time to read the response body, for streamed responses it is
+	// known once the body has been read or closed.
+	ContentTransfer time.Duration
+	Total           time.Duration
+	// Reused reports whether the connection had been used by a previous request.
+	Reused bool
+}
+
+// Trace records the Timings of the request in Response.Timings.
+type Trace struct{}
+
+func (Trace) Do(req *Request) error {
+	req.trace = true
+	return nil
+}
+
+// timingTrace records the Timings of one round trip through httptrace.
+type timingTrace struct {
+	mu        sync.Mutex
+	start     time.Time
+	dnsStart  time.Time
+	connStart time.Time
+	tlsStart  time.Time
+	wrote     time.Time
+	firstByte time.Time
+	timings   Timings
+}
+
+func newTimingTrace() *timingTrace {
+	return &timingTrace{start: time.Now()}
+}
+
+// request returns a copy of req whose timings are recorded.
+func (t *timingTrace) request(req *http.Request) *http.Request {
+	trace := &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { t.since(&t.timings.DNS, &t.dnsStart) },
+		ConnectStart:      func(string, string) { t.mark(&t.connStart) },
+		ConnectDone:       func(string, string, error) { t.since(&t.timings.Connect, &t.connStart) },
+		TLSHandshakeStart: func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { t.since(&t.timings.TLS, &t.tlsStart) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.timings.Reused = info.Reused
+			t.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.mark(&t.wrote) },
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+func (t *timingTrace) mark(at *time.Time) {
+	t.mu.Lock()
+	*at = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *timingTrace) since(d *time.Duration, start *time.Time) {
+	t.mu.Lock()
+	if !start.IsZero() {
+		*d = time.Since(*start)
+	}
+	t.mu.Unlock()
+}
+
+// headers records the timings known once the response headers are received.
+func (t *timingTrace) headers(timings *Timings) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.wrote.IsZero() && !t.firstByte.IsZero() {
+		t.timings.Wait = t.firstByte.Sub(t.wrote)
+	}
+	t.timings.Total = time.Since(t.start)
+	*timings = t.timings
+}
+
+// done records the timings known once the response body is read.
+func (t *timingTrace) done(timings *Timings) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.firstByte.IsZero() {
+		timings.ContentTransfer = time.Since(t.firstByte)
+	}
+	timings.Total = time.Since(t.start)
+}
+
+// timedBody records the content transfer of a streamed body when it is read or closed.
+type timedBody struct {
+	io.ReadCloser
+	once    sync.Once
+	trace   *timingTrace
+	timings *Timings
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.once.Do(func() { b.trace.done(b.timings) })
+	}
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.once.Do(func() { b.trace.done(b.timings) })
+	return b.ReadCloser.Close()
+}
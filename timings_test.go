@@ -0,0 +1,74 @@
+package requests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type timingsHook struct {
+	timings []*Timings
+}
+
+func (h *timingsHook) BeforeProcess(req *Request) {}
+
+func (h *timingsHook) AfterProcess(req *Request, resp *Response, err error) {
+	h.timings = append(h.timings, resp.Timings)
+}
+
+func TestTimings(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("x"))
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("y"))
+	}))
+	defer srv.Close()
+
+	hook := &timingsHook{}
+	client := NewClient(WithTransport(srv.Client().Transport), WithTrace())
+	client.AddHook(hook)
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("Get() err = %v", err)
+		}
+		timings := resp.Timings
+		if timings == nil {
+			t.Fatalf("Timings = nil")
+		}
+		if timings.Reused != (i == 1) {
+			t.Errorf("Reused = %v on request %d", timings.Reused, i)
+		}
+		if i == 0 && (timings.Connect <= 0 || timings.TLS <= 0) {
+			t.Errorf("Connect = %v, TLS = %v, want > 0", timings.Connect, timings.TLS)
+		}
+		if timings.Wait < 20*time.Millisecond || timings.ContentTransfer < 20*time.Millisecond {
+			t.Errorf("Wait = %v, ContentTransfer = %v, want >= 20ms", timings.Wait, timings.ContentTransfer)
+		}
+		if timings.Total < timings.Wait+timings.ContentTransfer {
+			t.Errorf("Total = %v, want >= %v", timings.Total, timings.Wait+timings.ContentTransfer)
+		}
+	}
+	if len(hook.timings) != 2 || hook.timings[0] == nil {
+		t.Errorf("AfterProcess() timings = %v", hook.timings)
+	}
+
+	resp, _ := client.Get(srv.URL, Stream{})
+	if resp.Timings.ContentTransfer != 0 {
+		t.Errorf("ContentTransfer = %v before reading the stream", resp.Timings.ContentTransfer)
+	}
+	resp.Text()
+	if resp.Timings.ContentTransfer < 20*time.Millisecond {
+		t.Errorf("ContentTransfer = %v after reading the stream, want >= 20ms", resp.Timings.ContentTransfer)
+	}
+
+	if resp, _ := Get(srv.URL, Trace{}); resp != nil && resp.Timings != nil {
+		t.Errorf("Timings recorded for a failed request")
+	}
+	if resp, _ := Get(testUrl); resp.Timings != nil {
+		t.Errorf("Timings = %v without Trace", resp.Timings)
+	}
+}
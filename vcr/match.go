@@ -0,0 +1,99 @@
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+)
+
+// Matcher reports whether a recorded request matches req, whose body is body.
+type Matcher func(req *http.Request, body []byte, recorded *Request) bool
+
+// DefaultMatcher matches the method, the url and the query parameters in any order.
+var DefaultMatcher = MatchAll(MatchMethod, MatchURL, MatchQuery)
+
+// MatchAll matches when all the matchers do.
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(req *http.Request, body []byte, recorded *Request) bool {
+		for _, match := range matchers {
+			if !match(req, body, recorded) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func MatchMethod(req *http.Request, _ []byte, recorded *Request) bool {
+	return req.Method == recorded.Method
+}
+
+// MatchURL matches the url without its query.
+func MatchURL(req *http.Request, _ []byte, recorded *Request) bool {
+	u, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	return req.URL.Scheme == u.Scheme && req.URL.Host == u.Host && req.URL.Path == u.Path
+}
+
+// MatchQuery matches the query parameters, in any order. Parameters scrubbed by ScrubQuery match any value.
+func MatchQuery(req *http.Request, _ []byte, recorded *Request) bool {
+	u, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	query, recordedQuery := req.URL.Query(), u.Query()
+	if len(query) != len(recordedQuery) {
+		return false
+	}
+	for name, values := range recordedQuery {
+		if !matchValues(query[name], values) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchBody matches the body, JSON bodies are compared by value.
+func MatchBody(_ *http.Request, body []byte, recorded *Request) bool {
+	if bytes.Equal(body, recorded.Body) {
+		return true
+	}
+	var v, recordedV interface{}
+	if json.Unmarshal(body, &v) != nil || json.Unmarshal(recorded.Body, &recordedV) != nil {
+		return false
+	}
+	return reflect.DeepEqual(v, recordedV)
+}
+
+// MatchHeaders matches the values of the given headers. Headers scrubbed by ScrubHeaders match any value.
+func MatchHeaders(names ...string) Matcher {
+	return func(req *http.Request, _ []byte, recorded *Request) bool {
+		for _, name := range names {
+			if !matchValues(req.Header.Values(name), recorded.Header.Values(name)) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// matchValues reports whether values match the recorded ones, a scrubbed value matches any value.
+// ScrubQuery keeps a single value for a parameter, which then matches all of its values.
+func matchValues(values, recorded []string) bool {
+	if len(recorded) == 1 && recorded[0] == redacted {
+		return len(values) > 0
+	}
+	if len(values) != len(recorded) {
+		return false
+	}
+	for i, value := range recorded {
+		if value != redacted && value != values[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,253 @@
+// Package vcr records the HTTP interactions of a client to a cassette file and replays them,
+// so that tests run without the servers they were recorded against:
+//
+//	recorder, err := vcr.New("testdata/users.json", vcr.ModeRecordNew)
+//	defer recorder.Stop()
+//	client := requests.NewClient(requests.WithTransport(recorder))
+package vcr
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInteractionNotFound will be throw out in ModeReplay when no recorded interaction matches a request
+var ErrInteractionNotFound = errors.New("go-requests/vcr: interaction not found")
+
+// Mode tells whether a Recorder sends the requests or replays them.
+type Mode int
+
+const (
+	// ModeRecord sends every request and records it, replacing the cassette.
+	ModeRecord Mode = iota
+	// ModeReplay only replays the cassette, which must exist.
+	ModeReplay
+	// ModeRecordNew replays the recorded interactions and records the requests not found.
+	ModeRecordNew
+)
+
+const redacted = "[REDACTED]"
+
+// Scrubber removes secrets from an interaction before the cassette is written.
+type Scrubber func(i *Interaction)
+
+// ScrubHeaders replaces the values of the given request and response headers.
+func ScrubHeaders(names ...string) Scrubber {
+	return func(i *Interaction) {
+		for _, header := range []http.Header{i.Request.Header, i.Response.Header} {
+			for _, name := range names {
+				if values := header.Values(name); len(values) > 0 {
+					header.Del(name)
+					for range values {
+						header.Add(name, redacted)
+					}
+				}
+			}
+		}
+	}
+}
+
+// ScrubQuery replaces the values of the given query parameters of the request url.
+func ScrubQuery(names ...string) Scrubber {
+	return func(i *Interaction) {
+		u, err := url.Parse(i.Request.URL)
+		if err != nil {
+			return
+		}
+		query := u.Query()
+		for _, name := range names {
+			if _, ok := query[name]; ok {
+				query.Set(name, redacted)
+			}
+		}
+		u.RawQuery = query.Encode()
+		i.Request.URL = u.String()
+	}
+}
+
+// Recorder is an http.RoundTripper recording and replaying a cassette, see Mode.
+// The cassette is written by Stop.
+type Recorder struct {
+	// Transport sends the requests which are not replayed, default http.DefaultTransport.
+	Transport http.RoundTripper
+	// Matcher finds the recorded interaction of a request, default DefaultMatcher.
+	Matcher Matcher
+	// Scrubbers are applied to the interactions written, the Authorization headers are always scrubbed.
+	Scrubbers []Scrubber
+
+	path     string
+	mode     Mode
+	mu       sync.Mutex
+	cassette *Cassette
+	changed  bool
+}
+
+// New returns a Recorder of the cassette at path.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode}
+	if mode == ModeRecord {
+		r.cassette = &Cassette{Version: cassetteVersion}
+		return r, nil
+	}
+	cassette, ok, err := loadCassette(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "go-requests/vcr: load cassette %s", path)
+	}
+	if !ok && mode == ModeReplay {
+		return nil, errors.Errorf("go-requests/vcr: cassette %s not found", path)
+	}
+	r.cassette = cassette
+	return r, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.mode != ModeRecord {
+		if i := r.find(req, body); i != nil {
+			closeBody(req)
+			return i.Response.response(req), nil
+		}
+		if r.mode == ModeReplay {
+			closeBody(req)
+			return nil, errors.Wrapf(ErrInteractionNotFound, "%s %s", req.Method, req.URL)
+		}
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	forward := req
+	if body != nil && req.GetBody == nil {
+		// the body of req was consumed by requestBody, the transport sends a copy.
+		forward = req.Clone(req.Context())
+		forward.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := transport.RoundTrip(forward)
+	if err != nil {
+		return nil, err
+	}
+	resp.Request = req
+	respBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{
+		Request:  Request{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone(), Body: body},
+		Response: Response{StatusCode: resp.StatusCode, Header: resp.Header.Clone(), Body: respBody},
+		replayed: true,
+	})
+	r.changed = true
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// find returns the first matching interaction not replayed yet, or else the last matching one.
+func (r *Recorder) find(req *http.Request, body []byte) *Interaction {
+	match := r.Matcher
+	if match == nil {
+		match = DefaultMatcher
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found *Interaction
+	for _, i := range r.cassette.Interactions {
+		if !match(req, body, &i.Request) {
+			continue
+		}
+		if !i.replayed {
+			i.replayed = true
+			return i
+		}
+		found = i
+	}
+	return found
+}
+
+// Stop writes the cassette if interactions were recorded.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.changed {
+		return nil
+	}
+	scrubbers := append([]Scrubber{ScrubHeaders("Authorization", "Proxy-Authorization")}, r.Scrubbers...)
+	cassette := &Cassette{Version: cassetteVersion}
+	for _, i := range r.cassette.Interactions {
+		scrubbed := &Interaction{
+			Request:  Request{Method: i.Request.Method, URL: i.Request.URL, Header: i.Request.Header.Clone(), Body: i.Request.Body},
+			Response: Response{StatusCode: i.Response.StatusCode, Header: i.Response.Header.Clone(), Body: i.Response.Body},
+		}
+		if scrubbed.Request.Header == nil {
+			scrubbed.Request.Header = http.Header{}
+		}
+		if scrubbed.Response.Header == nil {
+			scrubbed.Response.Header = http.Header{}
+		}
+		for _, scrub := range scrubbers {
+			scrub(scrubbed)
+		}
+		cassette.Interactions = append(cassette.Interactions, scrubbed)
+	}
+	if err := cassette.save(r.path); err != nil {
+		return errors.Wrapf(err, "go-requests/vcr: save cassette %s", r.path)
+	}
+	r.changed = false
+	return nil
+}
+
+// requestBody reads a copy of the body of req through GetBody, or else consumes and closes it,
+// without modifying req.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+	defer req.Body.Close()
+	return ioutil.ReadAll(req.Body)
+}
+
+// closeBody closes the body of a request which is not sent, as a RoundTripper must.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+func (r Response) response(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,214 @@
+package vcr
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	requests "github.com/fanjindong/go-requests"
+	"github.com/pkg/errors"
+)
+
+func newServer(hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(hits, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("X-Hit", string(rune('0'+n)))
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + string(body)))
+	}))
+}
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vcr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	var hits int32
+	srv := newServer(&hits)
+	recorder, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+	recorder.Scrubbers = []Scrubber{ScrubQuery("key")}
+	client := requests.NewClient(requests.WithTransport(recorder))
+	for _, q := range []string{"1", "2"} {
+		if _, err := client.Post(srv.URL+"/path", requests.Params{"q": q, "key": "secret"}, requests.Json{"q": q}, requests.Bearer("token")); err != nil {
+			t.Fatalf("Post() err = %v", err)
+		}
+	}
+	if err := recorder.Stop(); err != nil {
+		t.Fatalf("Stop() err = %v", err)
+	}
+	srv.Close()
+	data, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(data), "secret") || strings.Contains(string(data), "token") {
+		t.Errorf("cassette was not scrubbed: %s", data)
+	}
+
+	recorder, err = New(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+	recorder.Matcher = MatchAll(MatchMethod, MatchURL, MatchBody)
+	client = requests.NewClient(requests.WithTransport(recorder))
+	tests := []struct {
+		q    string
+		want string
+		hit  string
+	}{
+		{q: "2", want: `POST /path {"q":"2"}`, hit: "2"},
+		{q: "1", want: `POST /path {"q":"1"}`, hit: "1"},
+		{q: "1", want: `POST /path {"q":"1"}`, hit: "1"},
+	}
+	for _, tt := range tests {
+		resp, err := client.Post(srv.URL+"/path", requests.Json{"q": tt.q})
+		if err != nil {
+			t.Fatalf("replay Post() err = %v", err)
+		}
+		if got := resp.Text(); got != tt.want || resp.Header.Get("X-Hit") != tt.hit {
+			t.Errorf("replay got = %v %v, want %v %v", resp.Header.Get("X-Hit"), got, tt.hit, tt.want)
+		}
+	}
+	if _, err := client.Get(srv.URL + "/other"); !errors.Is(err, ErrInteractionNotFound) {
+		t.Errorf("replay unknown request err = %v, want ErrInteractionNotFound", err)
+	}
+	if hits != 2 {
+		t.Errorf("server hits = %v, want 2", hits)
+	}
+}
+
+func TestRecorderScrubbed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vcr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	var hits int32
+	srv := newServer(&hits)
+	defer srv.Close()
+	recorder, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+	recorder.Scrubbers = []Scrubber{ScrubQuery("api_key"), ScrubHeaders("X-Token")}
+	client := requests.NewClient(requests.WithTransport(recorder))
+	if _, err := client.Get(srv.URL+"/path", requests.Params{"api_key": "secret", "q": "1"}, requests.Header{"X-Token": "token"}); err != nil {
+		t.Fatalf("Get() err = %v", err)
+	}
+	if err := recorder.Stop(); err != nil {
+		t.Fatalf("Stop() err = %v", err)
+	}
+
+	// the scrubbed values match whatever the replayed request sends.
+	recorder, err = New(path, ModeReplay)
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+	recorder.Matcher = MatchAll(DefaultMatcher, MatchHeaders("X-Token"))
+	client = requests.NewClient(requests.WithTransport(recorder))
+	if _, err := client.Get(srv.URL+"/path", requests.Params{"api_key": "secret", "q": "1"}, requests.Header{"X-Token": "token"}); err != nil {
+		t.Errorf("replay Get() err = %v", err)
+	}
+	if _, err := client.Get(srv.URL+"/path", requests.Params{"api_key": "secret", "q": "2"}, requests.Header{"X-Token": "token"}); !errors.Is(err, ErrInteractionNotFound) {
+		t.Errorf("replay Get() with another query err = %v, want ErrInteractionNotFound", err)
+	}
+	if _, err := client.Get(srv.URL+"/path", requests.Params{"q": "1"}, requests.Header{"X-Token": "token"}); !errors.Is(err, ErrInteractionNotFound) {
+		t.Errorf("replay Get() without api_key err = %v, want ErrInteractionNotFound", err)
+	}
+	if hits != 1 {
+		t.Errorf("server hits = %v, want 1", hits)
+	}
+}
+
+func TestRecorderRecordNew(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vcr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	if _, err := New(path, ModeReplay); err == nil {
+		t.Errorf("New() of a missing cassette in ModeReplay err = nil")
+	}
+
+	var hits int32
+	srv := newServer(&hits)
+	defer srv.Close()
+	for _, urls := range [][]string{{"/a", "/a"}, {"/a", "/b"}} {
+		recorder, err := New(path, ModeRecordNew)
+		if err != nil {
+			t.Fatalf("New() err = %v", err)
+		}
+		recorder.Matcher = MatchAll(DefaultMatcher, MatchHeaders("X-Version"))
+		client := requests.NewClient(requests.WithTransport(recorder))
+		for _, u := range urls {
+			if _, err := client.Get(srv.URL+u, requests.Header{"X-Version": "1"}); err != nil {
+				t.Fatalf("Get() err = %v", err)
+			}
+		}
+		if err := recorder.Stop(); err != nil {
+			t.Fatalf("Stop() err = %v", err)
+		}
+	}
+	if hits != 2 {
+		t.Errorf("server hits = %v, want 2", hits)
+	}
+	cassette, _, err := loadCassette(path)
+	if err != nil || len(cassette.Interactions) != 2 {
+		t.Errorf("cassette = %+v, err = %v", cassette, err)
+	}
+}
+
+func TestBody(t *testing.T) {
+	for _, body := range []Body{Body("text"), Body{0xff, 0x00}} {
+		data, err := body.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Body
+		if err := got.UnmarshalJSON(data); err != nil || string(got) != string(body) {
+			t.Errorf("Body round trip of %q got = %q, err = %v", body, got, err)
+		}
+	}
+}
+
+func TestRecorderBody(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vcr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var hits int32
+	srv := newServer(&hits)
+	defer srv.Close()
+	recorder, err := New(filepath.Join(dir, "cassette.json"), ModeRecord)
+	if err != nil {
+		t.Fatalf("New() err = %v", err)
+	}
+	// a body without GetBody is recorded and sent, req is left as it was.
+	req, _ := http.NewRequest("POST", srv.URL+"/path", ioutil.NopCloser(strings.NewReader("data")))
+	body := req.Body
+	resp, err := recorder.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() err = %v", err)
+	}
+	got, _ := ioutil.ReadAll(resp.Body)
+	if string(got) != "POST /path data" || req.Body != body || resp.Request != req {
+		t.Errorf("RoundTrip() got = %s", got)
+	}
+	if recorded := recorder.cassette.Interactions[0].Request.Body; string(recorded) != "data" {
+		t.Errorf("recorded body = %s", recorded)
+	}
+}